@@ -17,14 +17,42 @@ class Me {
   bool get isModerator => role == 'moderator';
 }
 
+class SignedFileUrl {
+  final String url;
+  final DateTime expiresAt;
+
+  SignedFileUrl({required this.url, required this.expiresAt});
+
+  // Renewed a minute early so an image never loads with an expired url
+  bool get isValid => expiresAt.isAfter(DateTime.now().add(const Duration(minutes: 1)));
+}
+
 class ChatClient {
+  static const int protocolVersion = 1;
+  static const Duration requestTimeout = Duration(seconds: 10);
+
   final WebSocketChannel _channel;
   late final Stream<dynamic> _broadcastStream;
   final String baseUrl;
 
+  // Token for /upload and /files/ from get_file_token
+  String? _fileToken;
+  DateTime? _fileTokenExpiresAt;
+
+  // Signed download urls by file name, images can't send the token header
+  final Map<String, SignedFileUrl> _signedUrls = {};
+  final Map<String, Future<String?>> _pendingUrls = {};
+
   ChatClient(this.baseUrl, String username, String password)
       : _channel = WebSocketChannel.connect(Uri.parse("ws://$baseUrl/ws?username=$username&password=$password")) {
     _broadcastStream = _channel.stream.asBroadcastStream();
+    sendJson({
+      "type": "hello",
+      "payload": {
+        "protocol_version": protocolVersion,
+        "capabilities": <String>[],
+      }
+    });
   }
 
   void sendMessage(String text) {
@@ -61,12 +89,98 @@ class ChatClient {
     });
   }
 
+  // Sends a request and waits for the first reply it matches
+  Future<Map<String, dynamic>?> _request(Map<String, dynamic> data, bool Function(Map<String, dynamic>) matches) async {
+    final reply = _broadcastStream
+        .map((message) => jsonDecode(message) as Map<String, dynamic>)
+        .firstWhere(matches)
+        .timeout(requestTimeout);
+    sendJson(data);
+    try {
+      return await reply;
+    } catch (e) {
+      print("No reply to ${data['type']}: $e");
+      return null;
+    }
+  }
+
+  Future<String?> fileToken() async {
+    final expiresAt = _fileTokenExpiresAt;
+    if (_fileToken != null && expiresAt != null && expiresAt.isAfter(DateTime.now().add(const Duration(minutes: 1)))) {
+      return _fileToken;
+    }
+
+    final reply = await _request({"type": "get_file_token"}, (decoded) => decoded['type'] == 'file_token');
+    if (reply == null) return null;
+    _fileToken = reply['payload']['token'];
+    _fileTokenExpiresAt = DateTime.parse(reply['payload']['expires_at']);
+    return _fileToken;
+  }
+
+  // Message content is a /files/ url, the server wants the file name
+  String _fileName(String content) {
+    var name = content;
+    if (name.startsWith("/files/")) {
+      name = name.substring("/files/".length);
+    }
+    return name.startsWith("/") ? name.substring(1) : name;
+  }
+
+  bool _isExternal(String content) => content.startsWith("http://") || content.startsWith("https://");
+
+  // Signed url already known for the content, null until signedFileUrl completes
+  String? cachedFileUrl(String content) {
+    if (_isExternal(content)) return content;
+    final signed = _signedUrls[_fileName(content)];
+    if (signed == null || !signed.isValid) return null;
+    return "http://$baseUrl${signed.url}";
+  }
+
+  // Full url to show a file, signed with get_file_url
+  Future<String?> signedFileUrl(String content) {
+    final cached = cachedFileUrl(content);
+    if (cached != null) return Future.value(cached);
+
+    final name = _fileName(content);
+    return _pendingUrls.putIfAbsent(name, () async {
+      final reply = await _request({
+        "type": "get_file_url",
+        "payload": {"name": name}
+      }, (decoded) => decoded['type'] == 'file_url' && decoded['payload']['name'] == name);
+      _pendingUrls.remove(name);
+      if (reply == null) return null;
+
+      _signedUrls[name] = SignedFileUrl(
+        url: reply['payload']['url'],
+        expiresAt: DateTime.parse(reply['payload']['expires_at']),
+      );
+      return "http://$baseUrl${reply['payload']['url']}";
+    });
+  }
+
+  // The upload answer carries a signed url, no need to ask for it again
+  void _rememberSignedUrl(String url, String signedUrl) {
+    final expires = int.tryParse(Uri.parse(signedUrl).queryParameters['expires'] ?? '');
+    if (expires == null) return;
+    _signedUrls[_fileName(url)] = SignedFileUrl(
+      url: signedUrl,
+      expiresAt: DateTime.fromMillisecondsSinceEpoch(expires * 1000),
+    );
+  }
+
   Future<String?> uploadFile(dynamic file) async {
     try {
+      final token = await fileToken();
+      if (token == null) {
+        print("No file token, can't upload");
+        return null;
+      }
+
       final uploadUrl = Uri.parse("http://$baseUrl/upload");
       print("Uploading to $uploadUrl");
       
       var request = http.MultipartRequest('POST', uploadUrl);
+      request.headers['Authorization'] = 'Bearer $token';
       
       if (kIsWeb) {
         if (file.bytes == null) {
@@ -111,6 +225,9 @@ class ChatClient {
          try {
            final decoded = jsonDecode(respStr);
            if (decoded is Map<String, dynamic> && decoded.containsKey('url')) {
+             if (decoded['signed_url'] is String) {
+               _rememberSignedUrl(decoded['url'], decoded['signed_url']);
+             }
              return decoded['url'];
            }
          } catch (e) {
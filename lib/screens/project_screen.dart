@@ -208,13 +208,13 @@ class _ProjectScreenState extends State<ProjectScreen> {
           itemCount: _stickers.length,
           itemBuilder: (context, index) {
             final content = _stickers[index];
-            final url = _getFullUrl(content);
             return GestureDetector(
               onTap: () => _sendSticker(content),
-              child: Image.network(
-                url,
+              child: _fileImage(
+                content,
                 fit: BoxFit.cover,
-                errorBuilder: (context, error, stackTrace) => const Icon(Icons.broken_image),
+                loading: const Center(child: CircularProgressIndicator()),
+                error: const Icon(Icons.broken_image),
               ),
             );
           },
@@ -232,14 +232,28 @@ class _ProjectScreenState extends State<ProjectScreen> {
     super.dispose();
   }
   
-  String _getFullUrl(String content) {
-    if (content.startsWith("http://") || content.startsWith("https://")) {
-        return content;
-    }
-    if (content.startsWith("/")) {
-      return "http://${widget.ip}$content";
-    }
-    return "http://${widget.ip}/$content";
+  // Files need a signed url, the image can't send the token header
+  Widget _fileImage(String content, {double? width, required BoxFit fit, required Widget loading, required Widget error}) {
+    return FutureBuilder<String?>(
+      future: _client.signedFileUrl(content),
+      initialData: _client.cachedFileUrl(content),
+      builder: (context, snapshot) {
+        final url = snapshot.data;
+        if (url == null) {
+          return snapshot.connectionState == ConnectionState.done ? error : loading;
+        }
+        return Image.network(
+          url,
+          width: width,
+          fit: fit,
+          loadingBuilder: (context, child, loadingProgress) {
+            if (loadingProgress == null) return child;
+            return loading;
+          },
+          errorBuilder: (context, e, stackTrace) => error,
+        );
+      },
+    );
   }
 
   @override
@@ -417,7 +431,6 @@ class _ProjectScreenState extends State<ProjectScreen> {
   
                   Widget contentWidget;
                   if (msg.type == 'picture') {
-                    final fullUrl = _getFullUrl(msg.content);
                     contentWidget = GestureDetector(
                       onLongPress: () {
                         // Context menu
@@ -440,25 +453,20 @@ class _ProjectScreenState extends State<ProjectScreen> {
                       },
                       child: ClipRRect(
                         borderRadius: BorderRadius.circular(8),
-                        child: Image.network(
-                          fullUrl,
+                        child: _fileImage(
+                          msg.content,
                           width: 250,
                           fit: BoxFit.contain,
-                          loadingBuilder: (context, child, loadingProgress) {
-                             if (loadingProgress == null) return child;
-                             return Container(
-                               width: 200, height: 200,
-                               color: Colors.grey.withOpacity(0.2),
-                               child: const Center(child: CircularProgressIndicator()),
-                             );
-                          },
-                          errorBuilder: (context, error, stackTrace) {
-                             return Container(
-                                width: 200, height: 100,
-                                color: Colors.grey.withOpacity(0.2),
-                                child: const Center(child: Text("❌ Ошибка загрузки", style: TextStyle(fontSize: 12))),
-                             );
-                          },
+                          loading: Container(
+                            width: 200, height: 200,
+                            color: Colors.grey.withOpacity(0.2),
+                            child: const Center(child: CircularProgressIndicator()),
+                          ),
+                          error: Container(
+                            width: 200, height: 100,
+                            color: Colors.grey.withOpacity(0.2),
+                            child: const Center(child: Text("❌ Ошибка загрузки", style: TextStyle(fontSize: 12))),
+                          ),
                         ),
                       ),
                     );
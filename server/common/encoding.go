@@ -0,0 +1,237 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/tinylib/msgp/msgp"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Encoding is the wire format negotiated for a connection
+type Encoding string
+
+const (
+	EncodingJSON    Encoding = "json"
+	EncodingMsgpack Encoding = "msgpack"
+)
+
+// WebSocket subprotocols offered by the server, in order of preference
+const (
+	SubprotocolMsgpack = "patterns.msgpack"
+	SubprotocolJSON    = "patterns.json"
+)
+
+// Subprotocols is the list passed to the websocket upgrader
+var Subprotocols = []string{SubprotocolMsgpack, SubprotocolJSON}
+
+// EncodingForSubprotocol returns the encoding of a negotiated subprotocol.
+// No subprotocol means JSON so plain clients keep working.
+func EncodingForSubprotocol(subprotocol string) Encoding {
+	switch subprotocol {
+	case SubprotocolMsgpack:
+		return EncodingMsgpack
+	default:
+		return EncodingJSON
+	}
+}
+
+// OutgoingMessage is a message queued for delivery.
+// Its wire form is encoded once per encoding and cached,
+// so a broadcast isn't re-marshalled for every client.
+type OutgoingMessage struct {
+	Type    string
+	Payload interface{}
+
+	mu      sync.Mutex
+	encoded map[Encoding][]byte
+}
+
+// wireMessage is the JSON envelope as it goes over the socket
+type wireMessage struct {
+	Type    string      `json:"type"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+// jsonEnvelope is an incoming JSON message before the payload is decoded
+type jsonEnvelope struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Payload is the payload of an incoming message still in the encoding it
+// arrived in. Handlers decode it once, straight into their own type.
+type Payload struct {
+	encoding Encoding
+	data     []byte
+}
+
+// JSONPayload wraps a JSON payload
+func JSONPayload(data []byte) Payload {
+	return Payload{encoding: EncodingJSON, data: data}
+}
+
+// Empty says whether the message had no payload
+func (p Payload) Empty() bool {
+	switch p.encoding {
+	case EncodingMsgpack:
+		return len(p.data) == 0 || msgp.IsNil(p.data)
+	default:
+		return len(p.data) == 0 || string(p.data) == "null"
+	}
+}
+
+// Decode unmarshals the payload into v. Types of this package use their
+// generated MessagePack code, others are decoded by their json tags.
+func (p Payload) Decode(v interface{}) error {
+	if p.encoding != EncodingMsgpack {
+		return json.Unmarshal(p.data, v)
+	}
+
+	if unmarshaler, ok := v.(msgp.Unmarshaler); ok {
+		_, err := unmarshaler.UnmarshalMsg(p.data)
+		return err
+	}
+	decoder := msgpack.NewDecoder(bytes.NewReader(p.data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(v)
+}
+
+func NewMessage(messageType string, payload interface{}) *OutgoingMessage {
+	return &OutgoingMessage{
+		Type:    messageType,
+		Payload: payload,
+		encoded: make(map[Encoding][]byte, 2),
+	}
+}
+
+// Encode returns the message in the given encoding
+func (m *OutgoingMessage) Encode(encoding Encoding) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if data, ok := m.encoded[encoding]; ok {
+		return data, nil
+	}
+
+	var data []byte
+	var err error
+	switch encoding {
+	case EncodingMsgpack:
+		data, err = m.appendMsgpack(nil)
+	case EncodingJSON:
+		data, err = json.Marshal(wireMessage{Type: m.Type, Payload: m.Payload})
+	default:
+		err = fmt.Errorf("unknown encoding %q", encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m.encoded[encoding] = data
+	return data, nil
+}
+
+// appendMsgpack writes the envelope map, the payload with its generated
+// code when it has one
+func (m *OutgoingMessage) appendMsgpack(b []byte) ([]byte, error) {
+	if m.Payload == nil {
+		b = msgp.AppendMapHeader(b, 1)
+		return msgp.AppendString(msgp.AppendString(b, "type"), m.Type), nil
+	}
+
+	b = msgp.AppendMapHeader(b, 2)
+	b = msgp.AppendString(msgp.AppendString(b, "type"), m.Type)
+	b = msgp.AppendString(b, "payload")
+
+	if marshaler, ok := msgpMarshaler(m.Payload); ok {
+		return marshaler.MarshalMsg(b)
+	}
+	payload, err := marshalMsgpack(m.Payload)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, payload...), nil
+}
+
+// msgpMarshaler finds the generated code of a payload, which may be
+// declared on the pointer while payloads are usually passed by value
+func msgpMarshaler(payload interface{}) (msgp.Marshaler, bool) {
+	if marshaler, ok := payload.(msgp.Marshaler); ok {
+		return marshaler, true
+	}
+	value := reflect.ValueOf(payload)
+	pointer := reflect.New(value.Type())
+	pointer.Elem().Set(value)
+	marshaler, ok := pointer.Interface().(msgp.Marshaler)
+	return marshaler, ok
+}
+
+// DecodeMessage parses the envelope of an incoming frame, the payload is
+// left for the handler to decode in the same encoding
+func DecodeMessage(encoding Encoding, data []byte) (Message, error) {
+	var message Message
+
+	switch encoding {
+	case EncodingMsgpack:
+		return decodeMsgpackEnvelope(data)
+	case EncodingJSON:
+		var envelope jsonEnvelope
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return message, err
+		}
+		message.Type = envelope.Type
+		message.Payload = JSONPayload(envelope.Payload)
+	default:
+		return message, fmt.Errorf("unknown encoding %q", encoding)
+	}
+
+	return message, nil
+}
+
+func decodeMsgpackEnvelope(data []byte) (Message, error) {
+	var message Message
+
+	size, rest, err := msgp.ReadMapHeaderBytes(data)
+	if err != nil {
+		return message, err
+	}
+	for i := uint32(0); i < size; i++ {
+		var key []byte
+		key, rest, err = msgp.ReadMapKeyZC(rest)
+		if err != nil {
+			return message, err
+		}
+
+		switch string(key) {
+		case "type":
+			message.Type, rest, err = msgp.ReadStringBytes(rest)
+		case "payload":
+			start := rest
+			rest, err = msgp.Skip(rest)
+			if err == nil {
+				message.Payload = Payload{encoding: EncodingMsgpack, data: start[:len(start)-len(rest)]}
+			}
+		default:
+			rest, err = msgp.Skip(rest)
+		}
+		if err != nil {
+			return message, err
+		}
+	}
+	return message, nil
+}
+
+func marshalMsgpack(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -3,5 +3,6 @@ package common
 type ClientContext interface {
 	GetUsername() string
 	GetRole() string
-	Send(message []byte)
+	Supports(capability string) bool
+	Send(message *OutgoingMessage)
 }
@@ -0,0 +1,44 @@
+package common
+
+const (
+	// ProtocolVersion is the version spoken by this server
+	ProtocolVersion = 1
+	// MinProtocolVersion is the oldest client version still accepted
+	MinProtocolVersion = 1
+)
+
+// Capabilities exchanged in hello
+const (
+	CapabilityRooms  = "rooms"
+	CapabilityBinary = "binary"
+	CapabilityVideo  = "video"
+	// CapabilityResume reattaches the call of a reconnecting client on connect
+	CapabilityResume = "resume"
+	// CapabilityTrickleICE means candidates are sent separately from the SDP
+	CapabilityTrickleICE = "trickle_ice"
+)
+
+// ServerCapabilities is what this server implements
+var ServerCapabilities = []string{
+	CapabilityRooms,
+	CapabilityBinary,
+	CapabilityVideo,
+	CapabilityResume,
+	CapabilityTrickleICE,
+}
+
+// NegotiateCapabilities returns capabilities supported by both sides
+func NegotiateCapabilities(clientCapabilities []string) map[string]bool {
+	supported := make(map[string]bool, len(ServerCapabilities))
+	for _, capability := range ServerCapabilities {
+		supported[capability] = true
+	}
+
+	negotiated := make(map[string]bool, len(clientCapabilities))
+	for _, capability := range clientCapabilities {
+		if supported[capability] {
+			negotiated[capability] = true
+		}
+	}
+	return negotiated
+}
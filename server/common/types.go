@@ -1,6 +1,17 @@
 package common
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
+
+// Payload types get generated MessagePack code, field names come from the
+// json tags so both encodings look the same. Run go generate after changes.
+//go:generate go tool msgp -tests=false -io=false
+
+//msgp:tag json
+//msgp:newtime
+//msgp:ignore Message DataEvent
 
 const (
 	MessageTypeChat                     = "chat_message"
@@ -12,14 +23,17 @@ const (
 	MessageTypePromoteUserResponse      = "promote_user_response"
 	MessageTypeGetMessagesRequest       = "get_messages_request"
 	MessageTypeGetMessagesResponse      = "get_messages_response"
+	MessageTypeHello                    = "hello"
+	MessageTypeHelloAck                 = "hello_ack"
 
 	// System Messages
-	MessageTypeSystem       = "system_message"
-	MessageTypeSystemError  = "system_error_message"
-	MessageTypeUserJoinWS   = "user_joined_ws"
-	MessageTypeUserLeaveWS  = "user_left_ws"
-	MessageTypeUserJoinSFU  = "user_joined_sfu"
-	MessageTypeUserLeaveSFU = "user_left_sfu"
+	MessageTypeSystem         = "system_message"
+	MessageTypeSystemError    = "system_error_message"
+	MessageTypeResyncRequired = "resync_required"
+	MessageTypeUserJoinWS     = "user_joined_ws"
+	MessageTypeUserLeaveWS    = "user_left_ws"
+	MessageTypeUserJoinSFU    = "user_joined_sfu"
+	MessageTypeUserLeaveSFU   = "user_left_sfu"
 
 	// webrtc guys
 	// Custom
@@ -30,20 +44,67 @@ const (
 	MessageTypeSdpAnswer    = "sdp_answer"
 	MessageTypeIceCandidate = "ice_candidate"
 	MessageTypeLeaveCall    = "leave_call"
+	// Tracks
+	MessageTypeSetTrackSource   = "set_track_source"
+	MessageTypeTrackPublished   = "track_published"
+	MessageTypeTrackUnpublished = "track_unpublished"
+	MessageTypeTrackPaused      = "track_paused"
+	// Simulcast
+	MessageTypeSetPreferredLayer = "set_preferred_layer"
+	// Call lifecycle
+	MessageTypeCallInvite       = "call_invite"
+	MessageTypeIncomingCall     = "incoming_call"
+	MessageTypeCallAccept       = "call_accept"
+	MessageTypeCallDecline      = "call_decline"
+	MessageTypeCallInviteStatus = "call_invite_status"
+	MessageTypeCallEnded        = "call_ended"
+	MessageTypeGetCallHistory   = "get_call_history"
+	MessageTypeCallHistory      = "call_history"
+	// Call moderation
+	MessageTypeCallMuteParticipant   = "call_mute_participant"
+	MessageTypeCallRequestUnmute     = "call_request_unmute"
+	MessageTypeCallRemoveParticipant = "call_remove_participant"
+	MessageTypeParticipantMuted      = "participant_muted"
+	MessageTypeParticipantRemoved    = "participant_removed"
+	// Recording
+	MessageTypeCallStartRecording = "call_start_recording"
+	MessageTypeCallStopRecording  = "call_stop_recording"
+	MessageTypeRecordingStarted   = "recording_started"
+	MessageTypeRecordingStopped   = "recording_stopped"
+	// Call statistics
+	MessageTypeGetCallStats = "get_call_stats"
+	MessageTypeCallStats    = "call_stats"
+	// Audio levels
+	MessageTypeActiveSpeakerChanged = "active_speaker_changed"
+	MessageTypeAudioLevels          = "audio_levels"
+	// Media bot
+	MessageTypeCallPlayMedia = "call_play_media"
+	MessageTypeMediaState    = "media_state"
+	// Files
+	MessageTypeGetFileToken = "get_file_token"
+	MessageTypeFileToken    = "file_token"
+	MessageTypeGetFileURL   = "get_file_url"
+	MessageTypeFileURL      = "file_url"
 )
 
 type MessageSender interface {
-	Send(message []byte)
+	Send(message *OutgoingMessage)
 }
 
+// Message is an incoming message, the payload is decoded by its handler
 type Message struct {
-	Type    string          `json:"type"`
-	Payload json.RawMessage `json:"payload"`
+	Type    string
+	Payload Payload
 }
 
+// ChatTypeAttachment is a chat message with uploaded files, content is an optional caption
+const ChatTypeAttachment = "attachment"
+
 type ClientChatPayload struct {
 	Type    string `json:"type"`
 	Content string `json:"content"`
+	// FileIDs are the names returned by /upload, only for attachment messages
+	FileIDs []string `json:"file_ids,omitempty"`
 }
 
 type GetMessagesPayload struct {
@@ -51,30 +112,289 @@ type GetMessagesPayload struct {
 }
 
 type ServerChatPayload struct {
-	Sender  string `json:"sender"`
-	Role    string `json:"role"`
-	Type    string `json:"type"`
-	Content string `json:"content"`
+	Sender      string       `json:"sender"`
+	Role        string       `json:"role"`
+	Type        string       `json:"type"`
+	Content     string       `json:"content"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment describes an uploaded file of a chat message
+type Attachment struct {
+	// ID is the stored file name
+	ID           string `json:"id"`
+	OriginalName string `json:"original_name"`
+	Size         int64  `json:"size"`
+	MimeType     string `json:"mime_type"`
+	Checksum     string `json:"checksum"`
+	URL          string `json:"url"`
 }
+
 type PromoteUserPayload struct {
 	Username string `json:"username"`
 	NewRole  string `json:"new_role"`
 }
 
 type SdpPayload struct {
-	SDP string `json:"sdp"`
+	Type          string `json:"type"`
+	SDP           string `json:"sdp"`
+	NegotiationID uint64 `json:"negotiation_id,omitempty"`
 }
 
 type IceCandidatePayload struct {
 	Candidate map[string]interface{} `json:"candidate"`
 }
 
-type SignalingMessage struct {
+type HelloPayload struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities"`
+}
+
+type HelloAckPayload struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities"`
+	Username        string   `json:"username"`
+	Role            string   `json:"role"`
+}
+
+type JoinCallPayload struct {
+	RoomID string `json:"room_id"`
+	// AudioMix asks for one mixed audio track instead of a track per participant
+	AudioMix bool `json:"audio_mix,omitempty"`
+}
+
+// ICEServer is a STUN/TURN server handed to the client, same fields as config.ICEServer
+type ICEServer struct {
+	URLs       []string `json:"urls"`
+	Username   string   `json:"username,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+}
+
+type JoinCallSuccessPayload struct {
+	RoomID     string      `json:"room_id"`
+	ICEServers []ICEServer `json:"ice_servers"`
+	AudioMix   bool        `json:"audio_mix,omitempty"`
+	// Resumed means the existing call was reattached to this connection
+	Resumed bool `json:"resumed,omitempty"`
+}
+
+type TrackSourcePayload struct {
+	TrackID string `json:"track_id"`
+	Source  string `json:"source"`
+}
+
+type TrackPublishedPayload struct {
+	Username string `json:"username"`
+	TrackID  string `json:"track_id"`
+	StreamID string `json:"stream_id"`
+	Kind     string `json:"kind"`
+	Source   string `json:"source"`
+}
+
+type TrackPausedPayload struct {
+	TrackID string `json:"track_id"`
+	Paused  bool   `json:"paused"`
+	// Reason is "congestion" for now
+	Reason string `json:"reason"`
+}
+
+type PreferredLayerPayload struct {
+	TrackID string `json:"track_id"`
+	Layer   string `json:"layer"`
+}
+
+// Events on the in-call data channel, JSON like websocket messages
+const (
+	DataEventReaction  = "reaction"
+	DataEventChat      = "chat"
+	DataEventPointer   = "pointer"
+	DataEventRaiseHand = "raise_hand"
+	DataEventLowerHand = "lower_hand"
+	DataEventCallOn    = "call_on"
+	DataEventHandQueue = "hand_queue"
+)
+
+// DataEvent is one data channel message. From is set by the server.
+type DataEvent struct {
 	Type    string          `json:"type"`
-	Payload json.RawMessage `json:"payload"`
+	From    string          `json:"from,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type HandPayload struct {
+	Username string `json:"username,omitempty"`
+}
+
+type HandQueuePayload struct {
+	Queue []string `json:"queue"`
+}
+
+type CallInvitePayload struct {
+	// Username is who to call, everybody online when empty
+	Username string `json:"username,omitempty"`
+	// RoomID defaults to the inviter's call
+	RoomID string `json:"room_id,omitempty"`
+	// Room calls the members of the room instead of a user
+	Room bool `json:"room,omitempty"`
+}
+
+type IncomingCallPayload struct {
+	InviteID       string `json:"invite_id"`
+	From           string `json:"from"`
+	RoomID         string `json:"room_id"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+}
+
+type CallInviteReplyPayload struct {
+	InviteID string `json:"invite_id"`
+	AudioMix bool   `json:"audio_mix,omitempty"`
+}
+
+type CallInviteStatusPayload struct {
+	InviteID string `json:"invite_id"`
+	// Username is the invited user
+	Username string `json:"username"`
+	RoomID   string `json:"room_id"`
+	Status   string `json:"status"`
+}
+
+type CallEndedPayload struct {
+	// CallID is the history entry, 0 if saving failed
+	CallID          int64     `json:"call_id"`
+	RoomID          string    `json:"room_id"`
+	StartedAt       time.Time `json:"started_at"`
+	EndedAt         time.Time `json:"ended_at"`
+	DurationSeconds int64     `json:"duration_seconds"`
+	Participants    []string  `json:"participants"`
+}
+
+type GetCallHistoryPayload struct {
+	Limit int `json:"limit"`
+}
+
+type CallParticipantPayload struct {
+	Username string `json:"username"`
+	// Kind is "audio" or "video", ignored by call_remove_participant
+	Kind string `json:"kind,omitempty"`
+}
+
+type ParticipantMutedPayload struct {
+	Username string `json:"username"`
+	Kind     string `json:"kind"`
+	Muted    bool   `json:"muted"`
+	By       string `json:"by"`
+}
+
+type ParticipantRemovedPayload struct {
+	Username string `json:"username"`
+	By       string `json:"by"`
+}
+
+type RecordingStartedPayload struct {
+	RecordingID string `json:"recording_id"`
+	RoomID      string `json:"room_id"`
+	StartedBy   string `json:"started_by"`
+}
+
+type RecordingStoppedPayload struct {
+	RecordingID string `json:"recording_id"`
+	RoomID      string `json:"room_id"`
+	// StoppedBy is empty when the recording ended with the call
+	StoppedBy string `json:"stopped_by,omitempty"`
+	// URL of the manifest, empty if saving failed
+	URL string `json:"url,omitempty"`
+}
+
+type GetCallStatsPayload struct {
+	RoomID string `json:"room_id,omitempty"`
+}
+
+// ParticipantStats is the latest quality sample of one participant.
+// Jitter and loss are measured on the media the participant publishes.
+type ParticipantStats struct {
+	Username   string  `json:"username"`
+	RTTMs      float64 `json:"rtt_ms"`
+	JitterMs   float64 `json:"jitter_ms"`
+	PacketLoss float64 `json:"packet_loss"`
+	// PacketsLost is cumulative since the participant joined
+	PacketsLost int64 `json:"packets_lost"`
+	// InboundBitrate is what the server receives from the participant, bits/s
+	InboundBitrate uint64 `json:"inbound_bitrate"`
+	// OutboundBitrate is what the server sends to the participant, bits/s
+	OutboundBitrate uint64 `json:"outbound_bitrate"`
+	// EstimatedBitrate is how much the server may send to the participant, bits/s
+	EstimatedBitrate uint64 `json:"estimated_bitrate,omitempty"`
+	// PausedTracks are videos not sent to the participant because of congestion
+	PausedTracks []string `json:"paused_tracks,omitempty"`
+}
+
+type CallStatsPayload struct {
+	RoomID       string             `json:"room_id"`
+	Participants []ParticipantStats `json:"participants"`
+}
+
+type ActiveSpeakerPayload struct {
+	Username string `json:"username"`
+}
+
+type AudioLevelsPayload struct {
+	// Levels are smoothed speaking levels from 0 (silence) to 1 (loudest)
+	Levels map[string]float64 `json:"levels"`
+}
+
+type CallPlayMediaPayload struct {
+	// Action is play, pause, resume, stop or volume
+	Action string `json:"action"`
+	// File is the name of an uploaded Ogg/Opus file
+	File string `json:"file,omitempty"`
+	// ToneHz plays a sine test tone when no file is given
+	ToneHz float64 `json:"tone_hz,omitempty"`
+	// Text is keyed as Morse code on the tone
+	Text string `json:"text,omitempty"`
+	// DurationSeconds limits a plain tone, 0 plays until stopped
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	Loop            bool    `json:"loop,omitempty"`
+	// Volume goes from 0 to 2, 1 plays the source unchanged
+	Volume *float64 `json:"volume,omitempty"`
+}
+
+type MediaStatePayload struct {
+	RoomID   string `json:"room_id"`
+	Username string `json:"username"`
+	TrackID  string `json:"track_id"`
+	// State is playing, paused or stopped
+	State  string  `json:"state"`
+	Source string  `json:"source,omitempty"`
+	Volume float64 `json:"volume"`
+	Loop   bool    `json:"loop,omitempty"`
+	// By is empty when the media ended by itself
+	By string `json:"by,omitempty"`
+}
+
+type FileTokenPayload struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type GetFileURLPayload struct {
+	// Name of the file, a /files/ url is accepted too
+	Name string `json:"name"`
+}
+
+type FileURLPayload struct {
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ResyncPayload struct {
+	Missed uint64 `json:"missed"`
 }
 
 type ActiveClients struct {
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	RoomID   string `json:"room_id,omitempty"`
+	// Bot is set for server-side participants like the media bot
+	Bot bool `json:"bot,omitempty"`
 }
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+type Config struct {
+	Port   string       `json:"port"`
+	WebRTC WebRTCConfig `json:"webrtc"`
+	Files  FilesConfig  `json:"files"`
+}
+
+type FilesConfig struct {
+	// MaxUploadBytes is the upload limit per role, roles not listed get DefaultMaxUploadBytes
+	MaxUploadBytes        map[string]int64 `json:"max_upload_bytes"`
+	DefaultMaxUploadBytes int64            `json:"default_max_upload_bytes"`
+	// AllowedTypes are the accepted MIME types as sniffed from the content,
+	// empty accepts every type the server knows
+	AllowedTypes []string `json:"allowed_types"`
+	// Secret signs file tokens and download urls, random on every start when empty
+	Secret string `json:"secret"`
+}
+
+type ICEServer struct {
+	URLs       []string `json:"urls"`
+	Username   string   `json:"username,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+}
+
+type TURNConfig struct {
+	Enabled bool `json:"enabled"`
+	// ListenAddress is the UDP address of the embedded TURN server
+	ListenAddress string `json:"listen_address"`
+	// PublicIP is the address relayed candidates are announced with
+	PublicIP string `json:"public_ip"`
+	Realm    string `json:"realm"`
+	// Secret signs the short-lived credentials handed to clients
+	Secret               string `json:"secret"`
+	CredentialTTLSeconds int    `json:"credential_ttl_seconds"`
+}
+
+type WebRTCConfig struct {
+	ICEServers []ICEServer `json:"ice_servers"`
+	TURN       TURNConfig  `json:"turn"`
+	// UDPPortMin and UDPPortMax limit ports used for ICE, 0 means any
+	UDPPortMin uint16 `json:"udp_port_min"`
+	UDPPortMax uint16 `json:"udp_port_max"`
+	// UDPMuxPort serves every peer connection from one UDP port, 0 disables it
+	UDPMuxPort int `json:"udp_mux_port"`
+	// NAT1To1IPs are public addresses announced instead of the host ones
+	NAT1To1IPs []string `json:"nat_1to1_ips"`
+	// Interfaces limits ICE gathering to these network interfaces
+	Interfaces []string `json:"interfaces"`
+	// StreamTokens maps WHIP/WHEP bearer tokens to the name used in the call,
+	// participants are named stream:<name>
+	StreamTokens map[string]string `json:"stream_tokens"`
+}
+
+// Default returns the configuration used when no file is given
+func Default() *Config {
+	return &Config{
+		Port: "8080",
+		WebRTC: WebRTCConfig{
+			ICEServers: []ICEServer{
+				{URLs: []string{"stun:stun.l.google.com:19302"}},
+			},
+			TURN: TURNConfig{
+				ListenAddress:        "0.0.0.0:3478",
+				Realm:                "patterns",
+				CredentialTTLSeconds: 3600,
+			},
+		},
+		Files: FilesConfig{
+			MaxUploadBytes: map[string]int64{
+				"admin":     100 << 20,
+				"moderator": 50 << 20,
+			},
+			DefaultMaxUploadBytes: 10 << 20,
+		},
+	}
+}
+
+// Load reads the JSON config over the defaults. A missing file is not an error.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.WebRTC.TURN.Enabled && (cfg.WebRTC.TURN.Secret == "" || cfg.WebRTC.TURN.PublicIP == "") {
+		return nil, errors.New("turn.secret and turn.public_ip are required when the TURN server is enabled")
+	}
+	if cfg.Files.DefaultMaxUploadBytes <= 0 {
+		return nil, errors.New("files.default_max_upload_bytes must be positive")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+type CallParticipant struct {
+	Username string    `json:"username"`
+	JoinedAt time.Time `json:"joined_at"`
+	// DurationSeconds is the time spent in the call, summed over rejoins
+	DurationSeconds int64 `json:"duration_seconds"`
+}
+
+type Call struct {
+	ID              int64             `json:"id"`
+	RoomID          string            `json:"room_id"`
+	StartedAt       time.Time         `json:"started_at"`
+	EndedAt         time.Time         `json:"ended_at"`
+	DurationSeconds int64             `json:"duration_seconds"`
+	Participants    []CallParticipant `json:"participants"`
+}
+
+// InsertCall - сохраняет завершённый звонок с участниками, возвращает его id
+func InsertCall(db *sql.DB, call Call) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec("INSERT INTO calls (room_id, started_at, ended_at, duration_seconds) VALUES (?, ?, ?, ?)",
+		call.RoomID, call.StartedAt, call.EndedAt, call.DurationSeconds)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	callID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	for _, participant := range call.Participants {
+		_, err := tx.Exec("INSERT INTO call_participants (call_id, username, joined_at, duration_seconds) VALUES (?, ?, ?, ?)",
+			callID, participant.Username, participant.JoinedAt, participant.DurationSeconds)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	return callID, tx.Commit()
+}
+
+// GetCallHistory - получает последние N звонков, в которых участвовал пользователь
+func GetCallHistory(db *sql.DB, username string, limit int) ([]Call, error) {
+	rows, err := db.Query(`SELECT c.id, c.room_id, c.started_at, c.ended_at, c.duration_seconds
+		FROM calls c JOIN call_participants p ON p.call_id = c.id
+		WHERE p.username = ? ORDER BY c.started_at DESC LIMIT ?`, username, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	calls := []Call{}
+	for rows.Next() {
+		var call Call
+		if err := rows.Scan(&call.ID, &call.RoomID, &call.StartedAt, &call.EndedAt, &call.DurationSeconds); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range calls {
+		participants, err := getCallParticipants(db, calls[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		calls[i].Participants = participants
+	}
+	return calls, nil
+}
+
+func getCallParticipants(db *sql.DB, callID int64) ([]CallParticipant, error) {
+	rows, err := db.Query("SELECT username, joined_at, duration_seconds FROM call_participants WHERE call_id = ? ORDER BY joined_at", callID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var participants []CallParticipant
+	for rows.Next() {
+		var participant CallParticipant
+		if err := rows.Scan(&participant.Username, &participant.JoinedAt, &participant.DurationSeconds); err != nil {
+			return nil, err
+		}
+		participants = append(participants, participant)
+	}
+	return participants, rows.Err()
+}
+
+// GetRoomMembers - получает всех, кто участвовал в звонках комнаты
+func GetRoomMembers(db *sql.DB, roomID string) ([]string, error) {
+	rows, err := db.Query(`SELECT DISTINCT p.username FROM call_participants p
+		JOIN calls c ON c.id = p.call_id WHERE c.room_id = ?`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		members = append(members, username)
+	}
+	return members, rows.Err()
+}
+
+// TookPartInCall - был ли пользователь в одном из завершённых звонков комнаты
+func TookPartInCall(db *sql.DB, username string, roomID string) (bool, error) {
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS (
+		SELECT 1 FROM call_participants p JOIN calls c ON c.id = p.call_id
+		WHERE c.room_id = ? AND p.username = ?)`, roomID, username).Scan(&exists)
+	return exists, err
+}
@@ -2,26 +2,50 @@ package database
 
 import (
 	"database/sql"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type Message struct {
+	ID      int64  `json:"-"`
 	Sender  string `json:"sender"`
 	Role    string `json:"role"`
 	Type    string `json:"type"`
 	Content string `json:"content"`
+	// Attachments are the files of an attachment message in their order
+	Attachments []File `json:"attachments,omitempty"`
 }
 
-// InsertMessage - сохраняет новое сообщение в БД
-func InsertMessage(db *sql.DB, sender, role, message_type, content string) error {
-	_, err := db.Exec("INSERT INTO messages (sender, role, type, content) VALUES (?, ?, ?, ?)", sender, role, message_type, content)
-	return err
+// InsertMessage - сохраняет новое сообщение в БД вместе с прикреплёнными файлами
+func InsertMessage(db *sql.DB, sender, role, message_type, content string, fileIDs ...string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO messages (sender, role, type, content) VALUES (?, ?, ?, ?)", sender, role, message_type, content)
+	if err != nil {
+		return err
+	}
+	messageID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for position, fileID := range fileIDs {
+		_, err := tx.Exec("INSERT INTO message_attachments (message_id, file_name, position) VALUES (?, ?, ?)", messageID, fileID, position)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 // GetLastMessages - получает последние N сообщений из БД
 func GetLastMessages(db *sql.DB, limit int) ([]Message, error) {
-	rows, err := db.Query("SELECT sender, role, type, content timestamp FROM messages ORDER BY timestamp DESC LIMIT ?", limit)
+	rows, err := db.Query("SELECT id, sender, role, type, content FROM messages ORDER BY timestamp DESC LIMIT ?", limit)
 	if err != nil {
 		return nil, err
 	}
@@ -30,10 +54,54 @@ func GetLastMessages(db *sql.DB, limit int) ([]Message, error) {
 	var messages []Message
 	for rows.Next() {
 		var msg Message
-		if err := rows.Scan(&msg.Sender, &msg.Role, &msg.Type, &msg.Content); err != nil {
+		if err := rows.Scan(&msg.ID, &msg.Sender, &msg.Role, &msg.Type, &msg.Content); err != nil {
 			return nil, err
 		}
 		messages = append(messages, msg)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := loadAttachments(db, messages); err != nil {
+		return nil, err
+	}
 	return messages, nil
 }
+
+// loadAttachments - подгружает файлы всех сообщений одним запросом
+func loadAttachments(db *sql.DB, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*Message, len(messages))
+	placeholders := make([]string, 0, len(messages))
+	args := make([]any, 0, len(messages))
+	for i := range messages {
+		byID[messages[i].ID] = &messages[i]
+		placeholders = append(placeholders, "?")
+		args = append(args, messages[i].ID)
+	}
+
+	rows, err := db.Query(`SELECT a.message_id, f.name, f.owner, f.conversation, f.original_name, f.size, f.mime_type, f.checksum, f.created_at
+		FROM message_attachments a JOIN files f ON f.name = a.file_name
+		WHERE a.message_id IN (`+strings.Join(placeholders, ", ")+`) ORDER BY a.message_id, a.position`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID int64
+		var file File
+		if err := rows.Scan(&messageID, &file.Name, &file.Owner, &file.Conversation, &file.OriginalName, &file.Size, &file.MimeType, &file.Checksum, &file.CreatedAt); err != nil {
+			return err
+		}
+		if msg, ok := byID[messageID]; ok {
+			msg.Attachments = append(msg.Attachments, file)
+		}
+	}
+	return rows.Err()
+}
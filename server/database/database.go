@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
 	"sync"
 
@@ -61,6 +62,109 @@ func InitDB(dataSourceName string) (*sql.DB, error) {
 			logger.Errorf("Failed to create table messages: %v", err)
 			return
 		}
+
+		createRecordingsTableSQL := `CREATE TABLE IF NOT EXISTS recordings (
+			"id" TEXT NOT NULL PRIMARY KEY,
+			"room_id" TEXT NOT NULL,
+			"started_by" TEXT NOT NULL,
+			"started_at" DATETIME NOT NULL,
+			"ended_at" DATETIME NOT NULL,
+			"url" TEXT NOT NULL
+		);`
+
+		_, err = db.Exec(createRecordingsTableSQL)
+		if err != nil {
+			logger.Errorf("Failed to create table recordings: %v", err)
+			return
+		}
+
+		createCallsTableSQL := `CREATE TABLE IF NOT EXISTS calls (
+			"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			"room_id" TEXT NOT NULL,
+			"started_at" DATETIME NOT NULL,
+			"ended_at" DATETIME NOT NULL,
+			"duration_seconds" INTEGER NOT NULL
+		);`
+
+		_, err = db.Exec(createCallsTableSQL)
+		if err != nil {
+			logger.Errorf("Failed to create table calls: %v", err)
+			return
+		}
+
+		createCallParticipantsTableSQL := `CREATE TABLE IF NOT EXISTS call_participants (
+			"call_id" INTEGER NOT NULL REFERENCES calls(id),
+			"username" TEXT NOT NULL,
+			"joined_at" DATETIME NOT NULL,
+			"duration_seconds" INTEGER NOT NULL
+		);`
+
+		_, err = db.Exec(createCallParticipantsTableSQL)
+		if err != nil {
+			logger.Errorf("Failed to create table call_participants: %v", err)
+			return
+		}
+
+		createCallQualityTableSQL := `CREATE TABLE IF NOT EXISTS call_quality (
+			"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			"room_id" TEXT NOT NULL,
+			"username" TEXT NOT NULL,
+			"started_at" DATETIME NOT NULL,
+			"ended_at" DATETIME NOT NULL,
+			"samples" INTEGER NOT NULL,
+			"avg_rtt_ms" REAL NOT NULL,
+			"avg_jitter_ms" REAL NOT NULL,
+			"avg_packet_loss" REAL NOT NULL,
+			"avg_inbound_bitrate" REAL NOT NULL,
+			"avg_outbound_bitrate" REAL NOT NULL
+		);`
+
+		_, err = db.Exec(createCallQualityTableSQL)
+		if err != nil {
+			logger.Errorf("Failed to create table call_quality: %v", err)
+			return
+		}
+
+		createFilesTableSQL := `CREATE TABLE IF NOT EXISTS files (
+			"name" TEXT NOT NULL PRIMARY KEY,
+			"owner" TEXT NOT NULL,
+			"conversation" TEXT NOT NULL DEFAULT '',
+			"original_name" TEXT NOT NULL DEFAULT '',
+			"size" INTEGER NOT NULL DEFAULT 0,
+			"mime_type" TEXT NOT NULL DEFAULT '',
+			"checksum" TEXT NOT NULL DEFAULT '',
+			"created_at" DATETIME NOT NULL
+		);`
+
+		_, err = db.Exec(createFilesTableSQL)
+		if err != nil {
+			logger.Errorf("Failed to create table files: %v", err)
+			return
+		}
+
+		// files was created without metadata at first
+		err = addMissingColumns(db, "files", [][2]string{
+			{"original_name", "TEXT NOT NULL DEFAULT ''"},
+			{"size", "INTEGER NOT NULL DEFAULT 0"},
+			{"mime_type", "TEXT NOT NULL DEFAULT ''"},
+			{"checksum", "TEXT NOT NULL DEFAULT ''"},
+		})
+		if err != nil {
+			logger.Errorf("Failed to upgrade table files: %v", err)
+			return
+		}
+
+		createMessageAttachmentsTableSQL := `CREATE TABLE IF NOT EXISTS message_attachments (
+			"message_id" INTEGER NOT NULL REFERENCES messages(id),
+			"file_name" TEXT NOT NULL REFERENCES files(name),
+			"position" INTEGER NOT NULL
+		);`
+
+		_, err = db.Exec(createMessageAttachmentsTableSQL)
+		if err != nil {
+			logger.Errorf("Failed to create table message_attachments: %v", err)
+			return
+		}
 	})
 
 	if err != nil {
@@ -69,6 +173,37 @@ func InitDB(dataSourceName string) (*sql.DB, error) {
 	return db, nil
 }
 
+// addMissingColumns adds the columns a table created by an older version lacks
+func addMissingColumns(db *sql.DB, table string, columns [][2]string) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		if existing[column[0]] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN "%s" %s`, table, column[0], column[1])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetDB return single copy of DB connection
 func GetDB() *sql.DB {
 	if db == nil {
@@ -103,6 +238,21 @@ func CreateUser(db *sql.DB, username string, password string) (string, error) {
 	return role, nil
 }
 
+// Authenticate returns the role of an existing user, unlike CreateUser it never registers
+func Authenticate(db *sql.DB, username string, password string) (string, error) {
+	var role string
+	var passwordDB string
+
+	err := db.QueryRow("SELECT role, password FROM users WHERE username = ?", username).Scan(&role, &passwordDB)
+	if err != nil {
+		return "", err
+	}
+	if password != passwordDB {
+		return "", errors.New("incorrect password")
+	}
+	return role, nil
+}
+
 func UpdateUser(db *sql.DB, clientUsername string, clientRole string) {
 	_, err := db.Exec("UPDATE users SET role = ? WHERE username = ?", clientRole, clientUsername)
 	if err != nil {
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+type File struct {
+	// Name is the file name inside the upload folder, used as the file id
+	Name  string `json:"name"`
+	Owner string `json:"owner"`
+	// Conversation limits access to its members, empty is visible to every user
+	Conversation string `json:"conversation,omitempty"`
+	OriginalName string `json:"original_name"`
+	Size         int64  `json:"size"`
+	MimeType     string `json:"mime_type"`
+	// Checksum is the hex SHA-256 of the content
+	Checksum  string    `json:"checksum"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InsertFile - сохраняет загруженный файл и его владельца
+func InsertFile(db *sql.DB, file File) error {
+	_, err := db.Exec(`INSERT INTO files (name, owner, conversation, original_name, size, mime_type, checksum, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		file.Name, file.Owner, file.Conversation, file.OriginalName, file.Size, file.MimeType, file.Checksum, file.CreatedAt)
+	return err
+}
+
+// GetFile - возвращает файл по имени, sql.ErrNoRows если его нет
+func GetFile(db *sql.DB, name string) (File, error) {
+	var file File
+	err := db.QueryRow(`SELECT name, owner, conversation, original_name, size, mime_type, checksum, created_at
+		FROM files WHERE name = ?`, name).
+		Scan(&file.Name, &file.Owner, &file.Conversation, &file.OriginalName, &file.Size, &file.MimeType, &file.Checksum, &file.CreatedAt)
+	return file, err
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// CallQuality is the averaged quality of one participant over a call
+type CallQuality struct {
+	RoomID             string    `json:"room_id"`
+	Username           string    `json:"username"`
+	StartedAt          time.Time `json:"started_at"`
+	EndedAt            time.Time `json:"ended_at"`
+	Samples            int       `json:"samples"`
+	AvgRTTMs           float64   `json:"avg_rtt_ms"`
+	AvgJitterMs        float64   `json:"avg_jitter_ms"`
+	AvgPacketLoss      float64   `json:"avg_packet_loss"`
+	AvgInboundBitrate  float64   `json:"avg_inbound_bitrate"`
+	AvgOutboundBitrate float64   `json:"avg_outbound_bitrate"`
+}
+
+// InsertCallQuality - сохраняет итоги качества звонка одной транзакцией
+func InsertCallQuality(db *sql.DB, summaries []CallQuality) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, q := range summaries {
+		_, err := tx.Exec(`INSERT INTO call_quality (room_id, username, started_at, ended_at, samples,
+			avg_rtt_ms, avg_jitter_ms, avg_packet_loss, avg_inbound_bitrate, avg_outbound_bitrate)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			q.RoomID, q.Username, q.StartedAt, q.EndedAt, q.Samples,
+			q.AvgRTTMs, q.AvgJitterMs, q.AvgPacketLoss, q.AvgInboundBitrate, q.AvgOutboundBitrate)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
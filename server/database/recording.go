@@ -0,0 +1,30 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Recording struct {
+	ID        string    `json:"id"`
+	RoomID    string    `json:"room_id"`
+	StartedBy string    `json:"started_by"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	// URL is where the recording manifest can be downloaded
+	URL string `json:"url"`
+}
+
+// InsertRecording - сохраняет завершённую запись звонка
+func InsertRecording(db *sql.DB, recording Recording) error {
+	_, err := db.Exec("INSERT INTO recordings (id, room_id, started_by, started_at, ended_at, url) VALUES (?, ?, ?, ?, ?, ?)",
+		recording.ID, recording.RoomID, recording.StartedBy, recording.StartedAt, recording.EndedAt, recording.URL)
+	return err
+}
+
+// GetRecordingRoom - возвращает комнату, в которой сделана запись
+func GetRecordingRoom(db *sql.DB, id string) (string, error) {
+	var roomID string
+	err := db.QueryRow("SELECT room_id FROM recordings WHERE id = ?", id).Scan(&roomID)
+	return roomID, err
+}
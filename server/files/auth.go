@@ -0,0 +1,157 @@
+package files
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"server/database"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// RecordingsDir is the folder inside UploadDir with one folder per call recording
+	RecordingsDir = "recordings"
+
+	// tokenTTL is how long a token from get_file_token is valid
+	tokenTTL = time.Hour
+	// signedURLTTL is how long a signed download url works without a token
+	signedURLTTL = 10 * time.Minute
+)
+
+var (
+	// secret signs tokens and urls, random unless configured
+	secret []byte
+	// conversationMember tells whether the user may see the files of a
+	// conversation. The chat has no conversations of its own, so a
+	// conversation is a call room id, see SetConversationAccess.
+	conversationMember = func(username string, conversation string) bool { return false }
+)
+
+func init() {
+	secret = make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+}
+
+// SetConversationAccess sets how membership of a conversation is checked.
+// The server passes sfu.TookPartIn: a conversation is a call room, its
+// members are everyone who is or ever was in a call of that room. Membership
+// doesn't expire, leaving the call keeps access to its files.
+func SetConversationAccess(check func(username string, conversation string) bool) {
+	conversationMember = check
+}
+
+// tokenClaims is what a file token says about its holder
+type tokenClaims struct {
+	Username string `json:"u"`
+	Role     string `json:"r"`
+	Expires  int64  `json:"e"`
+}
+
+// IssueToken returns a bearer token for /upload and /files/
+func IssueToken(username string, role string) (string, time.Time) {
+	expires := time.Now().Add(tokenTTL)
+	claims, _ := json.Marshal(tokenClaims{Username: username, Role: role, Expires: expires.Unix()})
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	return payload + "." + sign("token:"+payload), expires
+}
+
+func parseToken(token string) (tokenClaims, bool) {
+	var claims tokenClaims
+
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !validSignature("token:"+payload, signature) {
+		return claims, false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return claims, false
+	}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return claims, false
+	}
+	return claims, time.Now().Unix() <= claims.Expires
+}
+
+// authenticate reads the bearer token of a request
+func authenticate(r *http.Request) (tokenClaims, bool) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return tokenClaims{}, false
+	}
+	return parseToken(strings.TrimPrefix(header, "Bearer "))
+}
+
+// SignedURL is a download url that works without a token until it
+// expires, for embedding files where no header can be sent
+func SignedURL(name string) (string, time.Time) {
+	expires := time.Now().Add(signedURLTTL).Unix()
+	query := url.Values{
+		"expires":   {strconv.FormatInt(expires, 10)},
+		"signature": {sign(urlMessage(name, expires))},
+	}
+	return URL(name) + "?" + query.Encode(), time.Unix(expires, 0)
+}
+
+func validSignedURL(name string, query url.Values) bool {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	return validSignature(urlMessage(name, expires), query.Get("signature"))
+}
+
+func urlMessage(name string, expires int64) string {
+	return "file:" + name + ":" + strconv.FormatInt(expires, 10)
+}
+
+func sign(message string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(message))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func validSignature(message string, signature string) bool {
+	return hmac.Equal([]byte(sign(message)), []byte(signature))
+}
+
+// CanAccess says whether the user may download a file, name is relative
+// to UploadDir. Admins see everything, recordings are for the members of
+// the recorded call, uploads for their owner and their conversation.
+func CanAccess(username string, role string, name string) bool {
+	if role == "admin" {
+		return true
+	}
+	db := database.GetDB()
+
+	if parts := strings.Split(name, "/"); parts[0] == RecordingsDir {
+		if len(parts) < 3 {
+			return false
+		}
+		roomID, err := database.GetRecordingRoom(db, parts[1])
+		if err != nil {
+			// Recordings in progress aren't saved yet
+			return false
+		}
+		return conversationMember(username, roomID)
+	}
+
+	file, err := database.GetFile(db, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		logger.Errorf("Не удалось получить файл %s из БД: %v", name, err)
+		return false
+	}
+	return file.Owner == username || file.Conversation == "" || conversationMember(username, file.Conversation)
+}
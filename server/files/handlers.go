@@ -1,17 +1,228 @@
 package files
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"server/config"
+	"server/database"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 )
 
+// UploadDir is where uploaded files and recordings are stored and served from
+const UploadDir = "./uploads"
+
+const (
+	// multipartOverhead is allowed on top of the file for the form framing
+	multipartOverhead = 64 << 10
+	// multipartMemory is kept in memory, the rest of the form goes to temp files
+	multipartMemory = 10 << 20
+	// sniffLength is what http.DetectContentType looks at
+	sniffLength = 512
+	// maxOriginalName caps the stored client file name in bytes
+	maxOriginalName = 255
+)
+
+// uploadTypes maps the MIME types the server recognizes by content to the
+// extension the file is stored with
+var uploadTypes = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/bmp":       ".bmp",
+	"audio/mpeg":      ".mp3",
+	"audio/wave":      ".wav",
+	"application/ogg": ".ogg",
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+}
+
+var settings = config.Default().Files
+
+// Configure sets the upload limits, allowed types and signing secret
+func Configure(filesConfig config.FilesConfig) {
+	settings = filesConfig
+	if settings.Secret != "" {
+		secret = []byte(settings.Secret)
+	} else {
+		logger.Warn("files.secret is not set, file tokens and urls stop working on restart")
+	}
+}
+
+// URL returns the download url of a file relative to UploadDir
+func URL(name string) string {
+	return "/files/" + filepath.ToSlash(name)
+}
+
+// MaxUploadSize is the largest file the role may upload
+func MaxUploadSize(role string) int64 {
+	if limit, ok := settings.MaxUploadBytes[role]; ok {
+		return limit
+	}
+	return settings.DefaultMaxUploadBytes
+}
+
+// allowedType returns the extension for a sniffed type, false if it is refused
+func allowedType(contentType string) (string, bool) {
+	if len(settings.AllowedTypes) > 0 {
+		allowed := false
+		for _, t := range settings.AllowedTypes {
+			if t == contentType {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "", false
+		}
+		return uploadTypes[contentType], true
+	}
+
+	ext, ok := uploadTypes[contentType]
+	return ext, ok
+}
+
+// sniffType detects the MIME type from the content, parameters are dropped
+func sniffType(head []byte) string {
+	contentType := http.DetectContentType(head)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.TrimSpace(contentType)
+}
+
+// inlineType says whether browsers may show the type in place. Media can't
+// run scripts, anything else is served as a download.
+func inlineType(contentType string) bool {
+	switch {
+	case contentType == "image/svg+xml":
+		return false
+	case strings.HasPrefix(contentType, "image/"),
+		strings.HasPrefix(contentType, "audio/"),
+		strings.HasPrefix(contentType, "video/"),
+		contentType == "application/ogg":
+		return true
+	default:
+		return false
+	}
+}
+
+// typeByExtension is the reverse of uploadTypes
+func typeByExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	for contentType, known := range uploadTypes {
+		if known == ext {
+			return contentType
+		}
+	}
+	return ""
+}
+
+// RegisterLegacyUploads records the files uploaded before owners were
+// stored. They had no owner and were shared in the global chat, so they
+// stay visible to every user. Files without a row are denied.
+func RegisterLegacyUploads() error {
+	entries, err := os.ReadDir(UploadDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	db := database.GetDB()
+	registered := 0
+	for _, entry := range entries {
+		// Recordings are checked by their own table
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		_, err := database.GetFile(db, name)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		err = database.InsertFile(db, database.File{
+			Name:         name,
+			OriginalName: name,
+			Size:         info.Size(),
+			MimeType:     typeByExtension(filepath.Ext(name)),
+			CreatedAt:    info.ModTime(),
+		})
+		if err != nil {
+			return err
+		}
+		registered++
+	}
+	if registered > 0 {
+		logger.Infof("Зарегистрировано %d старых файлов без владельца", registered)
+	}
+	return nil
+}
+
+// HandleFileUpload stores an upload of the token holder
 func HandleFileUpload(w http.ResponseWriter, r *http.Request) {
-	r.ParseMultipartForm(10 << 20)
+	owner, ok := authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	handleUpload(w, r, owner)
+}
+
+// handleUpload stores the "myFile" form field under a new name. The size is
+// capped for the role and the type comes from the content, never from the
+// name or the Content-Type the client sent. An optional "conversation"
+// field limits who can download the file.
+func handleUpload(w http.ResponseWriter, r *http.Request, owner tokenClaims) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := MaxUploadSize(owner.Role)
+	r.Body = http.MaxBytesReader(w, r.Body, limit+multipartOverhead)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	conversation := r.FormValue("conversation")
+	if conversation != "" && owner.Role != "admin" && !conversationMember(owner.Username, conversation) {
+		http.Error(w, "Not a member of the conversation", http.StatusForbidden)
+		return
+	}
 
 	file, handler, err := r.FormFile("myFile")
 	if err != nil {
@@ -20,27 +231,128 @@ func HandleFileUpload(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	if err := os.MkdirAll("./uploads", os.ModePerm); err != nil {
+	if handler.Size > limit {
+		http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		http.Error(w, "Empty file", http.StatusBadRequest)
+		return
+	}
+	head = head[:n]
+
+	contentType := sniffType(head)
+	ext, ok := allowedType(contentType)
+	if !ok {
+		http.Error(w, "File type is not allowed: "+contentType, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if err := os.MkdirAll(UploadDir, os.ModePerm); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	ext := filepath.Ext(handler.Filename)
 	newFileName := uuid.New().String() + ext
-
-	dst, err := os.Create("./uploads/" + newFileName)
+	dstPath := filepath.Join(UploadDir, newFileName)
+	dst, err := os.Create(dstPath)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, file); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	checksum := sha256.New()
+	size, err := io.Copy(io.MultiWriter(dst, checksum), io.MultiReader(bytes.NewReader(head), file))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	stored := database.File{
+		Name:         newFileName,
+		Owner:        owner.Username,
+		Conversation: conversation,
+		OriginalName: originalName(handler.Filename),
+		Size:         size,
+		MimeType:     contentType,
+		Checksum:     hex.EncodeToString(checksum.Sum(nil)),
+		CreatedAt:    time.Now(),
+	}
+	if err == nil {
+		err = database.InsertFile(database.GetDB(), stored)
+	}
+	if err != nil {
+		logger.Errorf("Не удалось сохранить файл от %s: %v", owner.Username, err)
+		os.Remove(dstPath)
+		http.Error(w, "Failed to save the file", http.StatusInternalServerError)
 		return
 	}
 
-	filePath := "/files/" + newFileName
+	signedURL, _ := SignedURL(newFileName)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"url": filePath})
+	json.NewEncoder(w).Encode(struct {
+		database.File
+		URL       string `json:"url"`
+		SignedURL string `json:"signed_url"`
+	}{stored, URL(newFileName), signedURL})
+}
+
+// originalName keeps the base of the client file name for display only,
+// it is never used as a path
+func originalName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	if len(name) > maxOriginalName {
+		name = strings.ToValidUTF8(name[:maxOriginalName], "")
+	}
+	return name
+}
+
+// ServeFiles serves UploadDir to token holders with access and to signed
+// urls. Browsers must not guess types and only media is shown inline,
+// everything else is downloaded.
+func ServeFiles() http.Handler {
+	fileServer := http.FileServer(http.Dir(UploadDir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// No directory listings
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+
+		if !validSignedURL(name, r.URL.Query()) {
+			requester, ok := authenticate(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+			if !CanAccess(requester.Username, requester.Role, name) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+		}
+
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+
+		contentType := typeByExtension(path.Ext(r.URL.Path))
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		if !inlineType(contentType) {
+			w.Header().Set("Content-Disposition", "attachment")
+		}
+		fileServer.ServeHTTP(w, r)
+	})
 }
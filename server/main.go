@@ -5,17 +5,20 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"server/config"
 	"server/database"
 	"server/files"
+	"server/sfu"
 	"server/ws"
 )
 
 func withCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Expose-Headers", "Location")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -57,25 +60,50 @@ func printLocalIPs(port string) {
 }
 
 func main() {
+	// Config
+	cfg, err := config.Load("./config.json")
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	sfu.Configure(cfg.WebRTC)
+	files.Configure(cfg.Files)
+	// File conversations are call rooms, their members are the call participants
+	files.SetConversationAccess(sfu.TookPartIn)
+
+	turnServer, err := sfu.StartTURNServer(cfg.WebRTC.TURN)
+	if err != nil {
+		log.Fatalf("Failed to start TURN server: %v", err)
+	}
+	if turnServer != nil {
+		defer turnServer.Close()
+	}
+
 	// WS Manager
 	manager := ws.GetManager()
 	go manager.Run()
 
 	// Database init
-	_, err := database.InitDB("./chat.db")
+	_, err = database.InitDB("./chat.db")
 	if err != nil {
 		log.Fatalf("For some reason failed init database: %v", err)
 	}
+	if err := files.RegisterLegacyUploads(); err != nil {
+		log.Fatalf("Failed to register old uploads: %v", err)
+	}
 
-	fs := withCORS(http.FileServer(http.Dir("./uploads")))
+	fs := withCORS(files.ServeFiles())
 	http.Handle("/files/", withCORS(http.StripPrefix("/files/", fs)))
 	http.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
 		withCORS(http.HandlerFunc(files.HandleFileUpload)).ServeHTTP(w, r)
 	})
 
+	http.Handle("/whip/", withCORS(http.HandlerFunc(sfu.HandleWHIP)))
+	http.Handle("/whep/", withCORS(http.HandlerFunc(sfu.HandleWHEP)))
+	http.Handle("/admin/calls/stats", withCORS(http.HandlerFunc(sfu.HandleCallStatsHTTP)))
+
 	// HTTP Server
 	http.HandleFunc("/ws", ws.HandleWS)
-	port := "8080"
+	port := cfg.Port
 	printLocalIPs(port)
 	addr := "0.0.0.0:" + port
 	log.Fatal(http.ListenAndServe(addr, nil))
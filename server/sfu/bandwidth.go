@@ -0,0 +1,239 @@
+package sfu
+
+import (
+	"server/common"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor/pkg/cc"
+	"github.com/pion/webrtc/v4"
+)
+
+const (
+	// allocationInterval throttles reallocations, GCC updates much more often
+	allocationInterval = 500 * time.Millisecond
+	// audioBitrate is reserved for every audio track before any video
+	audioBitrate = 64_000
+	// videoMinBitrate is what a single-layer video needs to be worth sending
+	videoMinBitrate = 300_000
+	// resumeHeadroom keeps a paused video off until 25% more than it needs is available
+	resumeHeadroom = 1.25
+
+	pauseReasonCongestion = "congestion"
+)
+
+// bandwidthController follows the GCC estimate of what the server can send
+// to one subscriber and pauses the videos that don't fit
+type bandwidthController struct {
+	client *Client
+
+	mu            sync.Mutex
+	estimate      uint64
+	lastAllocated time.Time
+
+	// allocating serializes allocations
+	allocating sync.Mutex
+}
+
+func newBandwidthController(client *Client, estimator cc.BandwidthEstimator) *bandwidthController {
+	b := &bandwidthController{
+		client:   client,
+		estimate: uint64(estimator.GetTargetBitrate()),
+	}
+	estimator.OnTargetBitrateChange(func(bitrate int) {
+		b.onEstimate(uint64(bitrate))
+	})
+	return b
+}
+
+// newPeerConnection creates a peer connection with its bandwidth estimator,
+// nil if the API has no congestion controller
+func (m *Manager) newPeerConnection() (*webrtc.PeerConnection, cc.BandwidthEstimator, error) {
+	m.peerMu.Lock()
+	defer m.peerMu.Unlock()
+
+	// Left over from a peer connection that failed to build
+	select {
+	case <-m.estimators:
+	default:
+	}
+
+	peerConnection, err := m.api.NewPeerConnection(webrtc.Configuration{
+		ICEServers: serverICEServers(settings),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Interceptors are built by NewPeerConnection, the estimator is already there
+	select {
+	case estimator := <-m.estimators:
+		return peerConnection, estimator, nil
+	default:
+		return peerConnection, nil, nil
+	}
+}
+
+func (b *bandwidthController) onEstimate(bitrate uint64) {
+	b.mu.Lock()
+	b.estimate = bitrate
+	due := time.Since(b.lastAllocated) >= allocationInterval
+	if due {
+		b.lastAllocated = time.Now()
+	}
+	b.mu.Unlock()
+
+	if due {
+		b.allocate()
+	}
+}
+
+// tracksChanged reallocates after a track was added to or removed from the
+// subscriber, without waiting for the next estimate. Clients without an
+// estimator have a nil controller.
+func (b *bandwidthController) tracksChanged() {
+	if b == nil {
+		return
+	}
+	go b.allocate()
+}
+
+func (b *bandwidthController) currentEstimate() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.estimate
+}
+
+// allocate reserves the audio first and gives video what is left, screen
+// shares before cameras. Every video needs at least its lowest layer, those
+// that don't fit are paused until the estimate recovers. Simulcast tracks
+// share the rest to pick their layer.
+func (b *bandwidthController) allocate() {
+	b.allocating.Lock()
+	defer b.allocating.Unlock()
+
+	client := b.client
+	budget := b.currentEstimate()
+
+	var videos []*PublishedTrack
+	for _, track := range client.Room.tracks() {
+		if !track.subscribed(client.Username) {
+			continue
+		}
+		if track.Kind == webrtc.RTPCodecTypeAudio {
+			if budget > audioBitrate {
+				budget -= audioBitrate
+			} else {
+				budget = 0
+			}
+			continue
+		}
+		videos = append(videos, track)
+	}
+
+	sort.Slice(videos, func(i, j int) bool {
+		iScreen := videos[i].Source == TrackSourceScreen
+		if iScreen != (videos[j].Source == TrackSourceScreen) {
+			return iScreen
+		}
+		return videos[i].ID < videos[j].ID
+	})
+
+	var active []*PublishedTrack
+	for _, track := range videos {
+		needed := track.minBitrate()
+		required := float64(needed)
+		if track.subscriberPaused(client.Username) {
+			required *= resumeHeadroom
+		}
+
+		if float64(budget) < required {
+			track.setSubscriberPaused(client, true, budget)
+			continue
+		}
+		budget -= needed
+		active = append(active, track)
+		track.setSubscriberPaused(client, false, budget)
+	}
+
+	if len(active) == 0 {
+		return
+	}
+	share := budget / uint64(len(active))
+	for _, track := range active {
+		track.onBandwidthEstimate(client.Username, track.minBitrate()+share)
+	}
+}
+
+// minBitrate is what the lowest layer of a video needs
+func (t *PublishedTrack) minBitrate() uint64 {
+	if t.Simulcast {
+		return layerBitrates[LayerQuarter]
+	}
+	return videoMinBitrate
+}
+
+func (t *PublishedTrack) subscribed(username string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, ok := t.subscriptions[username]
+	return ok
+}
+
+func (t *PublishedTrack) subscriberPaused(username string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	sub, ok := t.subscriptions[username]
+	return ok && sub.paused
+}
+
+// setSubscriberPaused stops or restarts sending the track to a subscriber.
+// The sender just has no track while paused, nothing is renegotiated.
+func (t *PublishedTrack) setSubscriberPaused(client *Client, paused bool, budget uint64) {
+	t.mu.Lock()
+	sub, ok := t.subscriptions[client.Username]
+	if !ok || sub.paused == paused {
+		t.mu.Unlock()
+		return
+	}
+	sub.paused = paused
+	t.mu.Unlock()
+
+	var local webrtc.TrackLocal
+	if !paused {
+		local = sub.local
+	}
+	if err := sub.sender.ReplaceTrack(local); err != nil {
+		logger.Warnf("Failed to pause=%t %s for %s: %v", paused, t.ID, client.Username, err)
+		t.mu.Lock()
+		sub.paused = !paused
+		t.mu.Unlock()
+		return
+	}
+
+	if paused {
+		logger.Infof("Congestion: %s paused for %s, %d bit/s left", t.ID, client.Username, budget)
+	} else {
+		logger.Infof("%s resumed for %s", t.ID, client.Username)
+		t.RequestKeyframe()
+	}
+
+	client.Context.Send(common.NewMessage(common.MessageTypeTrackPaused, common.TrackPausedPayload{
+		TrackID: t.ID,
+		Paused:  paused,
+		Reason:  pauseReasonCongestion,
+	}))
+}
+
+// pausedTracks are the videos currently not sent to the client
+func (c *Client) pausedTracks() []string {
+	var paused []string
+	for _, track := range c.Room.tracks() {
+		if track.subscriberPaused(c.Username) {
+			paused = append(paused, track.ID)
+		}
+	}
+	sort.Strings(paused)
+	return paused
+}
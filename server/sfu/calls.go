@@ -0,0 +1,119 @@
+package sfu
+
+import (
+	"server/common"
+	"server/database"
+	"sort"
+	"time"
+)
+
+// callParticipant is how long a user stayed in the call, over all their joins
+type callParticipant struct {
+	joinedAt    time.Time
+	activeSince time.Time
+	duration    time.Duration
+}
+
+// join must be called with r.mu held
+func (r *Room) join(username string) {
+	now := time.Now()
+	participant, ok := r.participants[username]
+	if !ok {
+		participant = &callParticipant{joinedAt: now}
+		r.participants[username] = participant
+	}
+	participant.activeSince = now
+}
+
+// leave must be called with r.mu held
+func (r *Room) leave(username string) {
+	participant, ok := r.participants[username]
+	if !ok || participant.activeSince.IsZero() {
+		return
+	}
+	participant.duration += time.Since(participant.activeSince)
+	participant.activeSince = time.Time{}
+}
+
+// finishCall saves the call to the history and announces its end.
+// Called once the last participant left.
+func (r *Room) finishCall(endedAt time.Time) {
+	r.mu.RLock()
+	call := database.Call{
+		RoomID:          r.ID,
+		StartedAt:       r.StartedAt,
+		EndedAt:         endedAt,
+		DurationSeconds: int64(endedAt.Sub(r.StartedAt).Seconds()),
+	}
+	for username, participant := range r.participants {
+		call.Participants = append(call.Participants, database.CallParticipant{
+			Username:        username,
+			JoinedAt:        participant.joinedAt,
+			DurationSeconds: int64(participant.duration.Seconds()),
+		})
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(call.Participants, func(i, j int) bool {
+		return call.Participants[i].JoinedAt.Before(call.Participants[j].JoinedAt)
+	})
+
+	callID, err := database.InsertCall(database.GetDB(), call)
+	if err != nil {
+		logger.Errorf("Не удалось сохранить звонок в комнате '%s': %v", r.ID, err)
+	}
+
+	participants := make([]string, 0, len(call.Participants))
+	for _, participant := range call.Participants {
+		participants = append(participants, participant.Username)
+	}
+
+	EventsChannel <- Event{
+		RoomID: r.ID,
+		Type:   common.MessageTypeCallEnded,
+		Payload: common.CallEndedPayload{
+			CallID:          callID,
+			RoomID:          r.ID,
+			StartedAt:       call.StartedAt,
+			EndedAt:         call.EndedAt,
+			DurationSeconds: call.DurationSeconds,
+			Participants:    participants,
+		},
+	}
+}
+
+// ClientRoom returns the call room the user is in
+func (m *Manager) ClientRoom(username string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	client, ok := m.Clients[username]
+	if !ok {
+		return "", false
+	}
+	return client.Room.ID, true
+}
+
+// TookPartIn says whether the user joined the ongoing call of the room or
+// one of its finished calls
+func TookPartIn(username string, roomID string) bool {
+	m := GetManager()
+	m.mu.RLock()
+	room, active := m.Rooms[roomID]
+	m.mu.RUnlock()
+	if active {
+		room.mu.RLock()
+		_, joined := room.participants[username]
+		room.mu.RUnlock()
+		if joined {
+			return true
+		}
+	}
+
+	joined, err := database.TookPartInCall(database.GetDB(), username, roomID)
+	if err != nil {
+		logger.Errorf("Не удалось проверить участие %s в звонках комнаты '%s': %v", username, roomID, err)
+		return false
+	}
+	return joined
+}
@@ -0,0 +1,60 @@
+package sfu
+
+import (
+	"server/common"
+
+	"github.com/pion/webrtc/v4"
+)
+
+func (c *Client) addRecvTransceiver(kind webrtc.RTPCodecType, source TrackSource) {
+	transceiver, err := c.PeerConnection.AddTransceiverFromKind(
+		kind,
+		webrtc.RTPTransceiverInit{
+			Direction: webrtc.RTPTransceiverDirectionRecvonly,
+		},
+	)
+	if err != nil {
+		logger.Errorf("AddTransceiverFromKind %s failed: %v", kind, err)
+		return
+	}
+
+	c.mu.Lock()
+	c.sources[transceiver] = source
+	c.mu.Unlock()
+}
+
+// trackSource resolves what the remote track carries. A source declared by
+// the client wins, otherwise it comes from the transceiver the track arrived on.
+func (c *Client) trackSource(remoteTrack *webrtc.TrackRemote, receiver *webrtc.RTPReceiver) TrackSource {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if source, ok := c.declaredSources[remoteTrack.ID()]; ok {
+		return source
+	}
+
+	for transceiver, source := range c.sources {
+		if transceiver.Receiver() == receiver {
+			return source
+		}
+	}
+
+	if remoteTrack.Kind() == webrtc.RTPCodecTypeAudio {
+		return TrackSourceMicrophone
+	}
+	return TrackSourceCamera
+}
+
+// canReceive reports whether the track may be forwarded to the client
+func (c *Client) canReceive(track *PublishedTrack) bool {
+	if track.Owner == c.Username || c.static {
+		return false
+	}
+	if track.Kind == webrtc.RTPCodecTypeVideo && !c.Context.Supports(common.CapabilityVideo) {
+		return false
+	}
+	if track.Kind == webrtc.RTPCodecTypeAudio && c.audioMix {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package sfu
+
+import (
+	"encoding/json"
+	"server/common"
+
+	"github.com/pion/webrtc/v4"
+)
+
+const (
+	// eventsChannelLabel is the data channel the server opens to every participant
+	eventsChannelLabel = "events"
+	// maxDataEventSize drops anything bigger than a small JSON event
+	maxDataEventSize = 4096
+)
+
+// openEventsChannel creates the participant data channel. It is added by a
+// renegotiation, static participants can't have one.
+func (c *Client) openEventsChannel(m *Manager) {
+	channel, err := c.PeerConnection.CreateDataChannel(eventsChannelLabel, nil)
+	if err != nil {
+		logger.Errorf("Failed to create data channel for %s: %v", c.Username, err)
+		return
+	}
+
+	channel.OnOpen(func() {
+		logger.Debugf("Data channel of %s is open", c.Username)
+		c.sendDataEvent(c.Room.handQueueEvent())
+	})
+	channel.OnMessage(func(message webrtc.DataChannelMessage) {
+		m.handleDataEvent(c, message)
+	})
+
+	c.mu.Lock()
+	c.events = channel
+	c.mu.Unlock()
+}
+
+func (c *Client) eventsChannel() *webrtc.DataChannel {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.events
+}
+
+func (c *Client) sendDataEvent(data []byte) {
+	channel := c.eventsChannel()
+	if channel == nil || channel.ReadyState() != webrtc.DataChannelStateOpen {
+		return
+	}
+	if err := channel.SendText(string(data)); err != nil {
+		logger.Debugf("Data channel send to %s failed: %v", c.Username, err)
+	}
+}
+
+// handleDataEvent relays reactions, chat and pointer moves to the other
+// members and keeps the raised-hand queue
+func (m *Manager) handleDataEvent(client *Client, message webrtc.DataChannelMessage) {
+	if len(message.Data) > maxDataEventSize {
+		logger.Warnf("Data event from %s is too big: %d bytes", client.Username, len(message.Data))
+		return
+	}
+
+	var event common.DataEvent
+	if err := json.Unmarshal(message.Data, &event); err != nil {
+		logger.Debugf("Invalid data event from %s: %v", client.Username, err)
+		return
+	}
+	// Clients can't speak for somebody else
+	event.From = client.Username
+	room := client.Room
+
+	switch event.Type {
+	case common.DataEventReaction, common.DataEventChat, common.DataEventPointer:
+		data := toJSON(event)
+		for _, member := range room.members() {
+			if member != client {
+				member.sendDataEvent(data)
+			}
+		}
+
+	case common.DataEventRaiseHand:
+		if room.raiseHand(client.Username) {
+			room.broadcastDataEvent(room.handQueueEvent())
+		}
+
+	case common.DataEventLowerHand, common.DataEventCallOn:
+		var target common.HandPayload
+		if len(event.Payload) > 0 {
+			if err := json.Unmarshal(event.Payload, &target); err != nil {
+				return
+			}
+		}
+		if target.Username == "" {
+			target.Username = client.Username
+		}
+		// Participants lower their own hand, the rest is for moderators
+		ownHand := target.Username == client.Username && event.Type == common.DataEventLowerHand
+		if !ownHand && !moderatorRoles[client.Context.GetRole()] {
+			logger.Warnf("%s tried %s for %s without permission", client.Username, event.Type, target.Username)
+			return
+		}
+		if !room.lowerHand(target.Username) {
+			return
+		}
+
+		if event.Type == common.DataEventCallOn {
+			logger.Infof("'%s' дал слово '%s'", client.Username, target.Username)
+			event.Payload = toJSON(target)
+			room.broadcastDataEvent(toJSON(event))
+		}
+		room.broadcastDataEvent(room.handQueueEvent())
+
+	default:
+		logger.Debugf("Unknown data event %q from %s", event.Type, client.Username)
+	}
+}
+
+// raiseHand puts the user at the end of the queue, false if already there
+func (r *Room) raiseHand(username string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, raised := range r.hands {
+		if raised == username {
+			return false
+		}
+	}
+	r.hands = append(r.hands, username)
+	return true
+}
+
+// lowerHand removes the user from the queue, false if the hand wasn't raised
+func (r *Room) lowerHand(username string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, raised := range r.hands {
+		if raised == username {
+			r.hands = append(r.hands[:i], r.hands[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// handQueueEvent is the raised hands in the order they were raised
+func (r *Room) handQueueEvent() []byte {
+	r.mu.RLock()
+	queue := append([]string{}, r.hands...)
+	r.mu.RUnlock()
+
+	return toJSON(common.DataEvent{
+		Type:    common.DataEventHandQueue,
+		Payload: toJSON(common.HandQueuePayload{Queue: queue}),
+	})
+}
+
+func (r *Room) broadcastDataEvent(data []byte) {
+	if data == nil {
+		return
+	}
+	for _, member := range r.members() {
+		member.sendDataEvent(data)
+	}
+}
+
+func toJSON(value interface{}) json.RawMessage {
+	data, err := json.Marshal(value)
+	if err != nil {
+		logger.Errorf("Marshal %T failed: %v", value, err)
+		return nil
+	}
+	return data
+}
@@ -1,14 +1,12 @@
 package sfu
 
 import (
-	"encoding/json"
-	"log"
 	"server/common"
 
 	"github.com/pion/webrtc/v4"
 )
 
-func HandleSDPAnswer(username string, payload json.RawMessage) {
+func HandleSDPAnswer(username string, payload common.Payload) {
 	logger.Trace("HandleSDPAnswer Called")
 	m := GetManager()
 	m.mu.RLock()
@@ -20,17 +18,41 @@ func HandleSDPAnswer(username string, payload json.RawMessage) {
 		return
 	}
 
-	var answer webrtc.SessionDescription
-	if err := json.Unmarshal(payload, &answer); err != nil {
+	var answerPayload common.SdpPayload
+	if err := payload.Decode(&answerPayload); err != nil {
 		logger.Errorf("Ошибка парсинга Answer от %s: %v", username, err)
 		return
 	}
 
+	client.negotiationMu.Lock()
+
+	// Answers without an id come from old clients and are matched by state only
+	state := client.PeerConnection.SignalingState()
+	stale := state != webrtc.SignalingStateHaveLocalOffer ||
+		(answerPayload.NegotiationID != 0 && answerPayload.NegotiationID != client.negotiationID)
+	if stale {
+		logger.Warnf("Stale answer from %s rejected (negotiation_id=%d, current=%d, state=%s)",
+			username, answerPayload.NegotiationID, client.negotiationID, state)
+		client.negotiationMu.Unlock()
+		return
+	}
+
+	answer := webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: answerPayload.SDP}
 	if err := client.PeerConnection.SetRemoteDescription(answer); err != nil {
 		logger.Errorf("Ошибка установки RemoteDescription (Answer) для %s: %v", username, err)
+		client.negotiationMu.Unlock()
+		return
+	}
+	client.flushPendingCandidates()
+
+	pending := client.negotiationPending
+	client.negotiationMu.Unlock()
+
+	if pending {
+		client.negotiate()
 	}
 }
-func HandleICECandidate(username string, payload json.RawMessage) {
+func HandleICECandidate(username string, payload common.Payload) {
 	logger.Tracef("HandleICECandidate вызван для пользователя: %s", username)
 	m := GetManager()
 	m.mu.RLock()
@@ -43,36 +65,77 @@ func HandleICECandidate(username string, payload json.RawMessage) {
 	}
 
 	var candidate webrtc.ICECandidateInit
-	if err := json.Unmarshal(payload, &candidate); err != nil {
+	if err := payload.Decode(&candidate); err != nil {
 		logger.Errorf("Ошибка парсинга ICE Candidate от %s: %v", username, err)
 		return
 	}
-	if err := client.PeerConnection.AddICECandidate(candidate); err != nil {
+	if err := client.addRemoteCandidate(candidate); err != nil {
 		logger.Errorf("Ошибка добавления ICE Candidate для %s: %v", username, err)
 	}
 }
 
-func HandleJoinCall(context common.ClientContext) {
+func HandleJoinCall(context common.ClientContext, payload common.Payload) {
 	logger.Tracef("HandleJoinCall вызван для пользователя: %s", context.GetUsername())
+
+	var joinPayload common.JoinCallPayload
+	if !payload.Empty() {
+		if err := payload.Decode(&joinPayload); err != nil {
+			sendError(context, "Некорректные данные для команды join_call.")
+			return
+		}
+	}
+	JoinCall(context, joinPayload)
+}
+
+// JoinCall joins the call of the room, the default room when none is given
+func JoinCall(context common.ClientContext, joinPayload common.JoinCallPayload) {
 	m := GetManager()
+	if joinPayload.RoomID == "" {
+		joinPayload.RoomID = DefaultRoomID
+	}
 
-	client, err := m.AddClient(context)
+	// The user is still in a call, e.g. joining again after a reconnect
+	m.mu.RLock()
+	existing, inCall := m.Clients[context.GetUsername()]
+	m.mu.RUnlock()
+	if inCall {
+		if existing.static {
+			sendError(context, "Это имя уже занято трансляцией в звонке.")
+			return
+		}
+		if existing.Room.ID == joinPayload.RoomID {
+			m.resume(existing, context)
+			return
+		}
+		m.RemoveClient(existing.Username)
+	}
+
+	client, err := m.AddClient(context, joinPayload.RoomID, JoinOptions{AudioMix: joinPayload.AudioMix})
 	if err != nil {
 		logger.Errorf("Client adding error %v ", err)
+		sendError(context, "Не удалось войти в звонок.")
 		return
 	}
 
-	responseBytes, err := json.Marshal(common.Message{
-		Type: common.MessageTypeJoinCallSuccess,
-	})
-	if err != nil {
-		logger.Errorf("HandleJoinCall error: %v", err)
+	client.Context.Send(common.NewMessage(common.MessageTypeJoinCallSuccess, common.JoinCallSuccessPayload{
+		RoomID:     client.Room.ID,
+		ICEServers: ICEServersFor(client.Username),
+		AudioMix:   client.audioMix,
+	}))
+
+	if client.audioMix {
+		if err := client.Room.addMixListener(client); err != nil {
+			logger.Errorf("Не удалось подключить %s к микшеру: %v", client.Username, err)
+			sendError(context, "Не удалось включить смешанный звук.")
+		}
 	}
 
-	client.Context.Send(responseBytes)
+	if rec := client.Room.recording.Load(); rec != nil {
+		client.Context.Send(rec.startedMessage())
+	}
 }
 
-func HandleSDPOffer(context common.ClientContext, payload json.RawMessage) {
+func HandleSDPOffer(context common.ClientContext, payload common.Payload) {
 	logger.Tracef("HandleWebRTCOffer вызван для пользователя: %s", context.GetUsername())
 	m := GetManager()
 	m.mu.RLock()
@@ -82,97 +145,113 @@ func HandleSDPOffer(context common.ClientContext, payload json.RawMessage) {
 		return
 	}
 
-	var offer webrtc.SessionDescription
-	if err := json.Unmarshal(payload, &offer); err != nil {
+	var offerPayload common.SdpPayload
+	if err := payload.Decode(&offerPayload); err != nil {
+		logger.Errorf("Ошибка парсинга Offer от %s: %v", client.Username, err)
 		return
 	}
 
+	client.negotiationMu.Lock()
+
+	// Glare: the server is the polite peer, it drops its own offer
+	// and negotiates its changes again once this exchange is done
+	if client.PeerConnection.SignalingState() != webrtc.SignalingStateStable {
+		logger.Infof("Offer collision with %s, rolling back local offer", client.Username)
+		rollback := webrtc.SessionDescription{Type: webrtc.SDPTypeRollback}
+		if err := client.PeerConnection.SetLocalDescription(rollback); err != nil {
+			logger.Errorf("Rollback for %s failed: %v", client.Username, err)
+			client.negotiationMu.Unlock()
+			return
+		}
+		client.negotiationPending = true
+	}
+
+	offer := webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: offerPayload.SDP}
 	if err := client.PeerConnection.SetRemoteDescription(offer); err != nil {
+		logger.Errorf("Ошибка установки RemoteDescription (Offer) для %s: %v", client.Username, err)
+		client.negotiationMu.Unlock()
 		return
 	}
+	client.flushPendingCandidates()
 
 	// Отправляем существующие треки от "старичков" этому клиенту
 	m.sendExistingTracksToClient(client)
 
 	answer, err := client.PeerConnection.CreateAnswer(nil)
 	if err != nil {
+		logger.Errorf("Failed to create answer for %s: %v", client.Username, err)
+		client.negotiationMu.Unlock()
 		return
 	}
 
-	gatherComplete := webrtc.GatheringCompletePromise(client.PeerConnection)
-	if err := client.PeerConnection.SetLocalDescription(answer); err != nil {
+	if err := client.setLocalDescription(answer); err != nil {
+		logger.Errorf("Failed to set local description for %s: %v", client.Username, err)
+		client.negotiationMu.Unlock()
 		return
 	}
-	<-gatherComplete
 
-	// Отправляем Answer клиенту
-	payloadBytes, _ := json.Marshal(client.PeerConnection.LocalDescription())
-	answerBytes, err := json.Marshal(common.Message{
-		Type:    common.MessageTypeSdpAnswer,
-		Payload: payloadBytes,
-	})
-	if err != nil {
-		logger.Errorf("HandleSDPAnswer error: %v", err)
+	// Отправляем Answer клиенту, id оффера возвращается в ответе
+	answerPayload := sdpPayload(client.PeerConnection.LocalDescription())
+	answerPayload.NegotiationID = offerPayload.NegotiationID
+	client.Context.Send(common.NewMessage(common.MessageTypeSdpAnswer, answerPayload))
+
+	pending := client.negotiationPending
+	client.negotiationMu.Unlock()
+
+	if pending {
+		client.negotiate()
 	}
-	client.Context.Send(answerBytes)
 }
 
-func (manager *Manager) setupNegotiationHandler(client *Client) {
-	client.PeerConnection.OnNegotiationNeeded(func() {
-		logger.Infof(
-			"Negotiation needed for %s. Current signaling state: %s",
-			client.Username,
-			client.PeerConnection.SignalingState(),
-		)
-
-		if client.PeerConnection.SignalingState() != webrtc.SignalingStateStable {
-			logger.Warnf("Ignoring renegotiation offer from %s (state=%s)",
-				client.Username, client.PeerConnection.SignalingState())
-			return
+func (m *Manager) sendExistingTracksToClient(newClient *Client) {
+	for _, track := range newClient.Room.tracks() {
+		if !newClient.canReceive(track) {
+			continue
 		}
-		log.Print("Negotiation triggered")
-
-		client.mu.Lock()
-		defer client.mu.Unlock()
-
-		offer, err := client.PeerConnection.CreateOffer(nil)
-		if err != nil {
-			logger.Errorf("Failed to create offer for %s: %v", client.Username, err)
-			return
+		if _, err := m.attachTrack(newClient, track); err != nil {
+			logger.Errorf("Не удалось добавить существующий трек: %v", err)
 		}
+	}
+}
 
-		if err := client.PeerConnection.SetLocalDescription(offer); err != nil {
-			logger.Errorf("Failed to set local description for %s: %v", client.Username, err)
-			return
-		}
+// HandleSetTrackSource lets the client label a track before it sends the offer
+func HandleSetTrackSource(context common.ClientContext, payload common.Payload) {
+	m := GetManager()
+	m.mu.RLock()
+	client, ok := m.Clients[context.GetUsername()]
+	m.mu.RUnlock()
+	if !ok {
+		sendError(context, "Сначала нужно войти в звонок.")
+		return
+	}
 
-		logger.Tracef("Negotiation offer for %s", client.Username)
+	var sourcePayload common.TrackSourcePayload
+	if err := payload.Decode(&sourcePayload); err != nil {
+		sendError(context, "Некорректные данные для команды set_track_source.")
+		return
+	}
 
-		offerPayload, err := json.Marshal(offer)
-		if err != nil {
-			logger.Errorf("Failed to marshal offer for %s: %v", client.Username, err)
+	source := TrackSource(sourcePayload.Source)
+	switch source {
+	case TrackSourceMicrophone, TrackSourceCamera:
+	case TrackSourceScreen:
+		if !canShareScreen(context.GetRole()) {
+			sendError(context, "Демонстрация экрана недоступна для вашей роли.")
+			return
 		}
+	default:
+		sendError(context, "Неизвестный источник трека.")
+		return
+	}
 
-		offerBytes, err := json.Marshal(common.SignalingMessage{
-			Type:    common.MessageTypeSdpOffer,
-			Payload: offerPayload,
-		})
-		if err != nil {
-			logger.Errorf("Failed to send offer to %s: %v", client.Username, err)
-		}
-		client.Context.Send(offerBytes)
-	})
+	client.mu.Lock()
+	client.declaredSources[sourcePayload.TrackID] = source
+	client.mu.Unlock()
 }
 
-func (m *Manager) sendExistingTracksToClient(newClient *Client) {
-	m.mu.RLock()
-
-	for _, track := range m.TrackLocals {
-		if _, err := newClient.PeerConnection.AddTrack(track); err != nil {
-			logger.Errorf("Не удалось добавить существующий трек: %v", err)
-		}
-	}
-	m.mu.RUnlock()
+func sendError(context common.ClientContext, errorMessage string) {
+	errorPayload := map[string]string{"error": errorMessage}
+	context.Send(common.NewMessage(common.MessageTypeSystemError, errorPayload))
 }
 
 func GetSFUClients(context common.ClientContext) {
@@ -183,31 +262,31 @@ func GetSFUClients(context common.ClientContext) {
 
 	activeClientsInfo := make([]common.ActiveClients, 0, len(sfuManager.Clients))
 
-	for _, client := range sfuManager.Clients {
-		activeClientsInfo = append(activeClientsInfo, common.ActiveClients{
-			Username: client.Username,
-		})
-	}
-
-	activeClientsInfoBytes, err := json.Marshal(activeClientsInfo)
-	if err != nil {
-		logger.Errorf("Failed to marshal active clients: %v", err)
-		return
-	}
-
-	clientsToSend := common.Message{
-		Type:    common.MessageTypeActiveClientsSFUResponse,
-		Payload: activeClientsInfoBytes,
+	for _, room := range sfuManager.Rooms {
+		for _, client := range room.members() {
+			activeClientsInfo = append(activeClientsInfo, common.ActiveClients{
+				Username: client.Username,
+				Role:     client.Context.GetRole(),
+				RoomID:   room.ID,
+			})
+		}
+		if room.bot.Load() != nil {
+			activeClientsInfo = append(activeClientsInfo, common.ActiveClients{
+				Username: MediaBotUsername,
+				Role:     mediaBotRole,
+				RoomID:   room.ID,
+				Bot:      true,
+			})
+		}
 	}
 
-	clientsToSendBytes, err := json.Marshal(clientsToSend)
-	if err != nil {
-		logger.Errorf("Failed to marshal active clients: %v", err)
-	}
+	context.Send(common.NewMessage(common.MessageTypeActiveClientsSFUResponse, activeClientsInfo))
+}
 
-	context.Send(clientsToSendBytes)
-	if err != nil {
-		logger.Errorf("Failed to send active clients: %v", err)
+// sdpPayload keeps the sdp type as a string in every encoding
+func sdpPayload(description *webrtc.SessionDescription) common.SdpPayload {
+	return common.SdpPayload{
+		Type: description.Type.String(),
+		SDP:  description.SDP,
 	}
-
 }
@@ -0,0 +1,56 @@
+package sfu
+
+import (
+	"server/common"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// trickle reports whether candidates are exchanged one by one.
+// Without it the full candidate list goes inside the SDP.
+func (c *Client) trickle() bool {
+	return c.Context.Supports(common.CapabilityTrickleICE)
+}
+
+// addRemoteCandidate queues candidates that arrive before the remote description
+func (c *Client) addRemoteCandidate(candidate webrtc.ICECandidateInit) error {
+	c.mu.Lock()
+	if c.PeerConnection.RemoteDescription() == nil {
+		c.pendingCandidates = append(c.pendingCandidates, candidate)
+		c.mu.Unlock()
+		logger.Tracef("Queued ICE candidate for %s until remote description is set", c.Username)
+		return nil
+	}
+	c.mu.Unlock()
+
+	return c.PeerConnection.AddICECandidate(candidate)
+}
+
+// flushPendingCandidates applies queued candidates, call it after SetRemoteDescription
+func (c *Client) flushPendingCandidates() {
+	c.mu.Lock()
+	pending := c.pendingCandidates
+	c.pendingCandidates = nil
+	c.mu.Unlock()
+
+	for _, candidate := range pending {
+		if err := c.PeerConnection.AddICECandidate(candidate); err != nil {
+			logger.Errorf("Ошибка добавления отложенного ICE Candidate для %s: %v", c.Username, err)
+		}
+	}
+}
+
+// setLocalDescription sets the description and, for clients without trickle,
+// waits until every candidate is gathered into it
+func (c *Client) setLocalDescription(description webrtc.SessionDescription) error {
+	if c.trickle() {
+		return c.PeerConnection.SetLocalDescription(description)
+	}
+
+	gatherComplete := webrtc.GatheringCompletePromise(c.PeerConnection)
+	if err := c.PeerConnection.SetLocalDescription(description); err != nil {
+		return err
+	}
+	<-gatherComplete
+	return nil
+}
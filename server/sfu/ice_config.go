@@ -0,0 +1,125 @@
+package sfu
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"server/common"
+	"server/config"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
+
+var settings = config.Default().WebRTC
+
+// Configure sets the WebRTC options. Must be called before GetManager.
+func Configure(webrtcConfig config.WebRTCConfig) {
+	settings = webrtcConfig
+}
+
+// newSettingEngine applies port range, UDP mux, NAT mapping and interface filter
+func newSettingEngine(cfg config.WebRTCConfig) (webrtc.SettingEngine, error) {
+	settingEngine := webrtc.SettingEngine{}
+
+	if cfg.UDPPortMin != 0 || cfg.UDPPortMax != 0 {
+		if err := settingEngine.SetEphemeralUDPPortRange(cfg.UDPPortMin, cfg.UDPPortMax); err != nil {
+			return settingEngine, err
+		}
+	}
+
+	if cfg.UDPMuxPort != 0 {
+		udpListener, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4zero, Port: cfg.UDPMuxPort})
+		if err != nil {
+			return settingEngine, err
+		}
+		logger.Infof("ICE UDP mux listening on %s", udpListener.LocalAddr())
+		settingEngine.SetICEUDPMux(webrtc.NewICEUDPMux(logger, udpListener))
+	}
+
+	if len(cfg.NAT1To1IPs) > 0 {
+		settingEngine.SetNAT1To1IPs(cfg.NAT1To1IPs, webrtc.ICECandidateTypeHost)
+	}
+
+	if len(cfg.Interfaces) > 0 {
+		allowed := make(map[string]bool, len(cfg.Interfaces))
+		for _, name := range cfg.Interfaces {
+			allowed[name] = true
+		}
+		settingEngine.SetInterfaceFilter(func(name string) bool {
+			return allowed[name]
+		})
+	}
+
+	return settingEngine, nil
+}
+
+// serverICEServers are used by the SFU own peer connections
+func serverICEServers(cfg config.WebRTCConfig) []webrtc.ICEServer {
+	servers := make([]webrtc.ICEServer, 0, len(cfg.ICEServers))
+	for _, server := range cfg.ICEServers {
+		servers = append(servers, webrtc.ICEServer{
+			URLs:       server.URLs,
+			Username:   server.Username,
+			Credential: server.Credential,
+		})
+	}
+	return servers
+}
+
+// ICEServersFor returns the servers handed to a client, with short-lived
+// credentials for the embedded TURN server bound to its username
+func ICEServersFor(username string) []common.ICEServer {
+	servers := make([]common.ICEServer, 0, len(settings.ICEServers)+1)
+	for _, server := range settings.ICEServers {
+		servers = append(servers, common.ICEServer(server))
+	}
+
+	turn := settings.TURN
+	if !turn.Enabled {
+		return servers
+	}
+
+	turnUsername, password := turnCredentials(turn.Secret, username, time.Duration(turn.CredentialTTLSeconds)*time.Second)
+	_, port, err := net.SplitHostPort(turn.ListenAddress)
+	if err != nil {
+		logger.Errorf("Bad TURN listen address %q: %v", turn.ListenAddress, err)
+		return servers
+	}
+
+	return append(servers, common.ICEServer{
+		URLs:       []string{fmt.Sprintf("turn:%s:%s?transport=udp", turn.PublicIP, port)},
+		Username:   turnUsername,
+		Credential: password,
+	})
+}
+
+// turnCredentials follows the TURN REST API scheme:
+// username is "<expiry unix time>:<user>", password is base64(HMAC-SHA1(secret, username))
+func turnCredentials(secret string, username string, ttl time.Duration) (string, string) {
+	turnUsername := fmt.Sprintf("%d:%s", time.Now().Add(ttl).Unix(), username)
+	return turnUsername, turnPassword(secret, turnUsername)
+}
+
+func turnPassword(secret string, turnUsername string) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(turnUsername))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// turnCredentialsExpired checks the expiry encoded in a TURN username
+func turnCredentialsExpired(turnUsername string) bool {
+	expiry, _, found := strings.Cut(turnUsername, ":")
+	if !found {
+		return true
+	}
+	expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return true
+	}
+	return time.Now().Unix() > expiresAt
+}
@@ -0,0 +1,110 @@
+package sfu
+
+import (
+	"strings"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// isKeyframe reports whether the RTP payload starts a keyframe
+func isKeyframe(mimeType string, payload []byte) bool {
+	switch {
+	case strings.EqualFold(mimeType, webrtc.MimeTypeVP8):
+		return isVP8Keyframe(payload)
+	case strings.EqualFold(mimeType, webrtc.MimeTypeVP9):
+		return isVP9Keyframe(payload)
+	case strings.EqualFold(mimeType, webrtc.MimeTypeH264):
+		return isH264Keyframe(payload)
+	default:
+		return false
+	}
+}
+
+// isVP8Keyframe parses the VP8 payload descriptor (RFC 7741)
+func isVP8Keyframe(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+
+	startOfPartition := payload[0]&0x10 != 0
+	partitionID := payload[0] & 0x07
+	if !startOfPartition || partitionID != 0 {
+		return false
+	}
+
+	i := 1
+	if payload[0]&0x80 != 0 {
+		if len(payload) <= i {
+			return false
+		}
+		extension := payload[i]
+		i++
+		if extension&0x80 != 0 {
+			if len(payload) <= i {
+				return false
+			}
+			if payload[i]&0x80 != 0 {
+				i += 2
+			} else {
+				i++
+			}
+		}
+		if extension&0x40 != 0 {
+			i++
+		}
+		if extension&0x30 != 0 {
+			i++
+		}
+	}
+
+	if len(payload) <= i {
+		return false
+	}
+	// P bit of the VP8 payload header is 0 for keyframes
+	return payload[i]&0x01 == 0
+}
+
+// isVP9Keyframe checks the P (inter-picture) and B (begin of frame) bits
+func isVP9Keyframe(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+	return payload[0]&0x40 == 0 && payload[0]&0x08 != 0
+}
+
+// isH264Keyframe looks for IDR or SPS NAL units, also inside STAP-A and FU-A
+func isH264Keyframe(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+
+	isKeyNAL := func(nalType byte) bool {
+		return nalType == 5 || nalType == 7
+	}
+
+	nalType := payload[0] & 0x1F
+	switch nalType {
+	case 24:
+		i := 1
+		for i+2 < len(payload) {
+			size := int(payload[i])<<8 | int(payload[i+1])
+			i += 2
+			if i >= len(payload) {
+				return false
+			}
+			if isKeyNAL(payload[i] & 0x1F) {
+				return true
+			}
+			i += size
+		}
+		return false
+	case 28:
+		if len(payload) < 2 {
+			return false
+		}
+		start := payload[1]&0x80 != 0
+		return start && isKeyNAL(payload[1]&0x1F)
+	default:
+		return isKeyNAL(nalType)
+	}
+}
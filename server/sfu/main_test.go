@@ -0,0 +1,34 @@
+package sfu
+
+import (
+	"os"
+	"path/filepath"
+	"server/config"
+	"server/database"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "sfu-test")
+	if err != nil {
+		panic(err)
+	}
+	if _, err := database.InitDB(filepath.Join(dir, "chat.db")); err != nil {
+		panic(err)
+	}
+
+	// Peers connect over loopback, no STUN server to wait for
+	webrtcConfig := config.Default().WebRTC
+	webrtcConfig.ICEServers = nil
+	Configure(webrtcConfig)
+
+	// Nobody reads the SFU events in tests
+	go func() {
+		for range EventsChannel {
+		}
+	}()
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
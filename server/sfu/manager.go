@@ -1,11 +1,11 @@
 package sfu
 
 import (
-	"encoding/json"
-	"log"
+	"fmt"
 	"server/common"
 	"sync"
 
+	"github.com/pion/interceptor/pkg/cc"
 	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v4"
 )
@@ -18,70 +18,157 @@ var (
 
 func GetManager() *Manager {
 	once.Do(func() {
+		estimators := make(chan cc.BandwidthEstimator, 1)
+		api, err := newAPI(settings, estimators)
+		if err != nil {
+			logger.Errorf("Failed to build webrtc API, using defaults: %v", err)
+			api = webrtc.NewAPI()
+		}
+
 		manager = &Manager{
-			Clients:     make(map[string]*Client),
-			TrackLocals: make(map[string]*webrtc.TrackLocalStaticRTP),
-			mu:          sync.RWMutex{},
+			Clients: make(map[string]*Client),
+			Rooms:   make(map[string]*Room),
+			api:     api,
+			mu:      sync.RWMutex{},
+
+			estimators: estimators,
 		}
 	})
 	return manager
 }
 
-func (m *Manager) AddClient(context common.ClientContext) (*Client, error) {
-	peerConnection, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+// JoinOptions change how a participant takes part in the call
+type JoinOptions struct {
+	// AudioMix sends the room mixer output instead of every audio track
+	AudioMix bool
+	// ViewOnly participants don't publish, no receive transceivers are added
+	ViewOnly bool
+	// Static participants can't renegotiate (WHIP/WHEP), their
+	// tracks are fixed by the first offer/answer
+	Static bool
+}
+
+func (m *Manager) AddClient(context common.ClientContext, roomID string, options JoinOptions) (*Client, error) {
+	m.mu.RLock()
+	_, exists := m.Clients[context.GetUsername()]
+	m.mu.RUnlock()
+	if exists {
+		return nil, fmt.Errorf("%s is already in a call", context.GetUsername())
+	}
+
+	peerConnection, estimator, err := m.newPeerConnection()
 	if err != nil {
 		logger.Errorf(err.Error())
 		return nil, err
 	}
-	_, err = peerConnection.AddTransceiverFromKind(
-		webrtc.RTPCodecTypeAudio,
-		webrtc.RTPTransceiverInit{
-			Direction: webrtc.RTPTransceiverDirectionRecvonly,
-		},
-	)
-	if err != nil {
-		logger.Errorf("AddTransceiverFromKind audio failed: %v", err)
-	}
 
+	signaling := newSignaling(context)
 	newClient := &Client{
-		Username:       context.GetUsername(),
-		PeerConnection: peerConnection,
-		Context:        context,
-		mu:             sync.RWMutex{},
+		Username:        context.GetUsername(),
+		PeerConnection:  peerConnection,
+		Context:         signaling,
+		signaling:       signaling,
+		sources:         make(map[*webrtc.RTPTransceiver]TrackSource),
+		declaredSources: make(map[string]TrackSource),
+		muted:           make(map[webrtc.RTPCodecType]bool),
+		audioMix:        options.AudioMix,
+		static:          options.Static,
+		mu:              sync.RWMutex{},
+	}
+	if estimator != nil {
+		newClient.bandwidth = newBandwidthController(newClient, estimator)
+	}
+
+	// Transceiver order matters: the client offer is matched to them by kind,
+	// so the first video m-line is the camera and the second is the screen.
+	if !options.ViewOnly {
+		newClient.addRecvTransceiver(webrtc.RTPCodecTypeAudio, TrackSourceMicrophone)
+		if context.Supports(common.CapabilityVideo) {
+			newClient.addRecvTransceiver(webrtc.RTPCodecTypeVideo, TrackSourceCamera)
+			if canShareScreen(context.GetRole()) {
+				newClient.addRecvTransceiver(webrtc.RTPCodecTypeVideo, TrackSourceScreen)
+			}
+		}
 	}
 
 	m.mu.Lock()
+	room := m.getOrCreateRoom(roomID)
+	room.mu.Lock()
+	room.Clients[newClient.Username] = newClient
+	room.join(newClient.Username)
+	room.mu.Unlock()
+	newClient.Room = room
 	m.Clients[newClient.Username] = newClient
 	m.mu.Unlock()
 
-	logger.Infof("Joined %s", newClient.Username)
-	EventsChannel <- Event{InitiatorUsername: newClient.Username, Type: common.MessageTypeUserJoinSFU}
+	logger.Infof("Joined %s to room '%s'", newClient.Username, room.ID)
+	EventsChannel <- Event{InitiatorUsername: newClient.Username, RoomID: room.ID, Type: common.MessageTypeUserJoinSFU}
 
 	m.setupPeerConnectionHandlers(newClient)
+	if !options.Static {
+		newClient.openEventsChannel(m)
+	}
 
 	return newClient, nil
 }
 
 func (m *Manager) RemoveClient(username string) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	client, ok := m.Clients[username]
 	if !ok {
+		m.mu.Unlock()
 		return
 	}
+	delete(m.Clients, username)
+	m.mu.Unlock()
+
+	room := client.Room
+
+	client.mu.RLock()
+	trackIDs := append([]string(nil), client.TrackIDs...)
+	client.mu.RUnlock()
 
-	if len(client.TrackIDs) > 0 {
-		logger.Infof("Удаляем %d треков от клиента %s", len(client.TrackIDs), username)
-		for _, trackID := range client.TrackIDs {
-			delete(m.TrackLocals, trackID)
+	if len(trackIDs) > 0 {
+		logger.Infof("Удаляем %d треков от клиента %s", len(trackIDs), username)
+		for _, trackID := range trackIDs {
+			if track, ok := room.track(trackID); ok {
+				m.unpublishTrack(room, track)
+			}
 		}
 	}
+	m.detachSubscriber(room, client)
+	room.speakers.forget(username)
+	handLowered := room.lowerHand(username)
+	room.stats.forget(username)
+	if mixer := room.audioMixer.Load(); mixer != nil {
+		mixer.removeListener(username)
+	}
+
+	m.mu.Lock()
+	room.mu.Lock()
+	delete(room.Clients, username)
+	room.leave(username)
+	empty := len(room.Clients) == 0 && m.Rooms[room.ID] == room
+	if empty {
+		delete(m.Rooms, room.ID)
+	}
+	room.mu.Unlock()
+	m.mu.Unlock()
+
+	if empty {
+		room.close()
+		logger.Infof("Room '%s' is empty and closed", room.ID)
+	}
 
 	client.PeerConnection.Close()
-	delete(m.Clients, username)
+	if client.static {
+		forgetStreamSession(username)
+	}
+	if handLowered {
+		room.broadcastDataEvent(room.handQueueEvent())
+	}
 
-	EventsChannel <- Event{InitiatorUsername: username, Type: common.MessageTypeUserLeaveSFU}
+	EventsChannel <- Event{InitiatorUsername: username, RoomID: room.ID, Type: common.MessageTypeUserLeaveSFU}
 	logger.Infof("Клиент '%s' удален из SFU.", username)
 
 }
@@ -90,21 +177,11 @@ func (manager *Manager) setupPeerConnectionHandlers(client *Client) {
 	manager.setupNegotiationHandler(client)
 
 	client.PeerConnection.OnICECandidate(func(candidate *webrtc.ICECandidate) {
-		if candidate == nil {
+		if candidate == nil || !client.trickle() {
 			return
 		}
 
-		candidateBytes, err := json.Marshal(candidate.ToJSON())
-		if err != nil {
-			logger.Errorf(err.Error())
-		}
-
-		candidateMessageBytes, err := json.Marshal(common.SignalingMessage{
-			Type:    common.MessageTypeIceCandidate,
-			Payload: candidateBytes,
-		})
-
-		client.Context.Send(candidateMessageBytes)
+		client.Context.Send(common.NewMessage(common.MessageTypeIceCandidate, candidate.ToJSON()))
 	})
 
 	client.PeerConnection.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
@@ -112,9 +189,8 @@ func (manager *Manager) setupPeerConnectionHandlers(client *Client) {
 
 		switch state {
 		case webrtc.PeerConnectionStateFailed:
-			log.Print("Failed to connect to Peer CLose")
-			logger.Error("Failed to connect to Peer CLose")
-			client.PeerConnection.Close()
+			logger.Warnf("Peer connection of %s failed, restarting ICE", client.Username)
+			client.restartICE()
 		case webrtc.PeerConnectionStateClosed:
 			logger.Info("Peer connection closed")
 			manager.RemoveClient(client.Username)
@@ -122,38 +198,99 @@ func (manager *Manager) setupPeerConnectionHandlers(client *Client) {
 	})
 
 	client.PeerConnection.OnTrack(func(remoteTrack *webrtc.TrackRemote, receiver *webrtc.RTPReceiver) {
-		logger.Infof("Получен track от '%s'! Тип: %s", client.Username, remoteTrack.Kind())
+		source := client.trackSource(remoteTrack, receiver)
+		logger.Infof("Получен track от '%s'! Тип: %s, источник: %s", client.Username, remoteTrack.Kind(), source)
 
-		localTrack, newTrackErr := webrtc.NewTrackLocalStaticRTP(remoteTrack.Codec().RTPCodecCapability, remoteTrack.ID(), remoteTrack.StreamID())
-		if newTrackErr != nil {
-			logger.Errorf("Не удалось создать локальный трек: %v", newTrackErr)
+		// The role is read from the connection, promotions apply during the call
+		if role := client.Context.GetRole(); source == TrackSourceScreen && !canShareScreen(role) {
+			logger.Warnf("Screen sharing is not allowed for %s (role=%s)", client.Username, role)
+			sendError(client.Context, "Демонстрация экрана недоступна для вашей роли.")
+			return
 		}
 
-		client.mu.Lock()
-		client.TrackIDs = append(client.TrackIDs, remoteTrack.ID())
-		client.mu.Unlock()
+		streamID := client.Username
+		if source == TrackSourceScreen {
+			streamID = client.Username + "-" + string(TrackSourceScreen)
+		}
 
-		manager.mu.Lock()
-		manager.TrackLocals[remoteTrack.ID()] = localTrack
-		manager.mu.Unlock()
+		track := &PublishedTrack{
+			StreamID:  streamID,
+			Owner:     client.Username,
+			Kind:      remoteTrack.Kind(),
+			Source:    source,
+			Simulcast: remoteTrack.RID() != "",
+
+			codec:         remoteTrack.Codec().RTPCodecCapability,
+			downTracks:    make(map[string]*downTrack),
+			subscriptions: make(map[string]*subscription),
+			publisher:     client.PeerConnection,
+			ssrcs:         map[string]webrtc.SSRC{remoteTrack.RID(): remoteTrack.SSRC()},
+			remoteID:      remoteTrack.ID(),
+
+			audioLevelExtID: audioLevelExtensionID(receiver),
+		}
+		track.muted.Store(client.isMuted(track.Kind))
+
+		// Every simulcast layer fires OnTrack, the first one creates the publication.
+		// Other tracks of the same source get a numbered id instead of replacing it.
+		room := client.Room
+		baseID := client.Username + "-" + string(source)
+		trackID := baseID
+		room.mu.Lock()
+		for n := 2; ; n++ {
+			existing, ok := room.Tracks[trackID]
+			if !ok {
+				break
+			}
+			if existing.Simulcast && track.Simulcast && existing.remoteID == track.remoteID {
+				room.mu.Unlock()
+				existing.addLayer(remoteTrack)
+				go manager.forwardTrack(room, existing, remoteTrack)
+				return
+			}
+			trackID = fmt.Sprintf("%s-%d", baseID, n)
+		}
+		track.ID = trackID
+
+		if !track.Simulcast {
+			localTrack, newTrackErr := webrtc.NewTrackLocalStaticRTP(track.codec, trackID, streamID)
+			if newTrackErr != nil {
+				room.mu.Unlock()
+				logger.Errorf("Не удалось создать локальный трек: %v", newTrackErr)
+				return
+			}
+			track.Local = localTrack
+		}
+		room.Tracks[trackID] = track
+		room.mu.Unlock()
 
-		go manager.forwardTrack(remoteTrack, localTrack)
+		client.mu.Lock()
+		client.TrackIDs = append(client.TrackIDs, trackID)
+		client.mu.Unlock()
 
-		manager.addTrackToOtherClients(client.Username, localTrack)
+		go manager.forwardTrack(room, track, remoteTrack)
 
+		manager.addTrackToOtherClients(room, track)
+		manager.announceTrack(room, track)
 	})
 
 }
 
-func (manager *Manager) forwardTrack(remoteTrack *webrtc.TrackRemote, localTrack *webrtc.TrackLocalStaticRTP) {
+func (manager *Manager) forwardTrack(room *Room, track *PublishedTrack, remoteTrack *webrtc.TrackRemote) {
+	layer := remoteTrack.RID()
+	defer func() {
+		if track.removeLayer(layer) == 0 {
+			manager.unpublishTrack(room, track)
+		}
+	}()
+
 	buf := make([]byte, 1500)
 	rtpPkt := &rtp.Packet{}
 
 	for {
 		i, _, readErr := remoteTrack.Read(buf)
 		if readErr != nil {
-			logger.Error(readErr.Error())
-			// TODO RemoveTrack
+			logger.Infof("Track %s (layer %q) ended: %v", track.ID, layer, readErr)
 			return
 		}
 
@@ -162,35 +299,70 @@ func (manager *Manager) forwardTrack(remoteTrack *webrtc.TrackRemote, localTrack
 			continue
 		}
 
+		if track.muted.Load() {
+			continue
+		}
+
+		if rec := room.recording.Load(); rec != nil {
+			rec.writeRTP(track, layer, rtpPkt)
+		}
+
+		if track.Kind == webrtc.RTPCodecTypeAudio {
+			if mixer := room.audioMixer.Load(); mixer != nil {
+				mixer.push(track.Owner, rtpPkt.Payload)
+			}
+		}
+
+		if track.audioLevelExtID != 0 {
+			if payload := rtpPkt.GetExtension(track.audioLevelExtID); payload != nil {
+				var audioLevel rtp.AudioLevelExtension
+				if err := audioLevel.Unmarshal(payload); err == nil {
+					room.speakers.observe(track.Owner, audioLevel.Level)
+				}
+			}
+		}
+
 		rtpPkt.Extension = false
 		rtpPkt.Extensions = nil
 
-		if writeErr := localTrack.WriteRTP(rtpPkt); writeErr != nil {
-			logger.Error(writeErr.Error())
-			return
+		if writeErr := track.writeRTP(layer, rtpPkt); writeErr != nil {
+			logger.Warnf("Write %s failed: %v", track.ID, writeErr)
 		}
 	}
 }
 
-func (manager *Manager) addTrackToOtherClients(username string, track *webrtc.TrackLocalStaticRTP) {
+func (manager *Manager) addTrackToOtherClients(room *Room, track *PublishedTrack) {
 
-	logger.Info("Adding track to other clients")
+	logger.Infof("Adding track to other clients of room '%s'", room.ID)
 
-	manager.mu.RLock()
-	clientsToUpdate := make([]*Client, 0)
-	for _, client := range manager.Clients {
-		if client.Username != username {
-			clientsToUpdate = append(clientsToUpdate, client)
+	for _, client := range room.members() {
+		if !client.canReceive(track) {
+			continue
 		}
-	}
-	manager.mu.RUnlock()
-
-	for _, client := range clientsToUpdate {
 		logger.Debugf("Add Track to %s", client.Username)
-		if _, err := client.PeerConnection.AddTrack(track); err != nil {
+		if _, err := manager.attachTrack(client, track); err != nil {
 			logger.Errorf("Failed add track to %s: %v", client.Username, err)
 		}
 		sendersCount := len(client.PeerConnection.GetSenders())
 		logger.Infof("✅ Трек успешно добавлен для %s. Всего треков теперь: %d", client.Username, sendersCount)
 	}
+	logger.Debugf("%s now has %d subscribers", track.ID, track.SenderCount())
+}
+
+// announceTrack tells everyone in the room who publishes what
+func (manager *Manager) announceTrack(room *Room, track *PublishedTrack) {
+	payload := common.TrackPublishedPayload{
+		Username: track.Owner,
+		TrackID:  track.ID,
+		StreamID: track.StreamID,
+		Kind:     track.Kind.String(),
+		Source:   string(track.Source),
+	}
+
+	message := common.NewMessage(common.MessageTypeTrackPublished, payload)
+	for _, client := range room.members() {
+		if client.canReceive(track) {
+			client.Context.Send(message)
+		}
+	}
 }
@@ -0,0 +1,162 @@
+package sfu
+
+import (
+	"fmt"
+	"server/config"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/cc"
+	"github.com/pion/interceptor/pkg/gcc"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v4"
+)
+
+const mimeTypeRTX = "video/rtx"
+
+// Bandwidth feedback is transport-cc, registered with the TWCC interceptors
+var videoRTCPFeedback = []webrtc.RTCPFeedback{
+	{Type: webrtc.TypeRTCPFBCCM, Parameter: "fir"},
+	{Type: webrtc.TypeRTCPFBNACK},
+	{Type: webrtc.TypeRTCPFBNACK, Parameter: "pli"},
+}
+
+// newMediaEngine registers the codecs the SFU can forward.
+// Payload types match what browsers usually offer.
+func newMediaEngine() (*webrtc.MediaEngine, error) {
+	mediaEngine := &webrtc.MediaEngine{}
+
+	if err := mediaEngine.RegisterCodec(webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{
+			MimeType:    webrtc.MimeTypeOpus,
+			ClockRate:   48000,
+			Channels:    2,
+			SDPFmtpLine: "minptime=10;useinbandfec=1",
+		},
+		PayloadType: 111,
+	}, webrtc.RTPCodecTypeAudio); err != nil {
+		return nil, err
+	}
+
+	// Audio levels feed the active speaker detection
+	if err := mediaEngine.RegisterHeaderExtension(
+		webrtc.RTPHeaderExtensionCapability{URI: sdp.AudioLevelURI},
+		webrtc.RTPCodecTypeAudio,
+	); err != nil {
+		return nil, err
+	}
+
+	videoCodecs := []webrtc.RTPCodecParameters{
+		{
+			RTPCodecCapability: webrtc.RTPCodecCapability{
+				MimeType:     webrtc.MimeTypeVP8,
+				ClockRate:    90000,
+				RTCPFeedback: videoRTCPFeedback,
+			},
+			PayloadType: 96,
+		},
+		{
+			RTPCodecCapability: webrtc.RTPCodecCapability{
+				MimeType:     webrtc.MimeTypeVP9,
+				ClockRate:    90000,
+				SDPFmtpLine:  "profile-id=0",
+				RTCPFeedback: videoRTCPFeedback,
+			},
+			PayloadType: 98,
+		},
+		{
+			RTPCodecCapability: webrtc.RTPCodecCapability{
+				MimeType:     webrtc.MimeTypeH264,
+				ClockRate:    90000,
+				SDPFmtpLine:  "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+				RTCPFeedback: videoRTCPFeedback,
+			},
+			PayloadType: 102,
+		},
+	}
+	for _, codec := range videoCodecs {
+		if err := mediaEngine.RegisterCodec(codec, webrtc.RTPCodecTypeVideo); err != nil {
+			return nil, err
+		}
+
+		// RTX stream for retransmissions, payload type right after the codec
+		if err := mediaEngine.RegisterCodec(webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{
+				MimeType:    mimeTypeRTX,
+				ClockRate:   90000,
+				SDPFmtpLine: fmt.Sprintf("apt=%d", codec.PayloadType),
+			},
+			PayloadType: codec.PayloadType + 1,
+		}, webrtc.RTPCodecTypeVideo); err != nil {
+			return nil, err
+		}
+	}
+
+	return mediaEngine, nil
+}
+
+const (
+	// Bounds of the send-side estimate for one subscriber, bits/s
+	initialBitrate = 1_000_000
+	minBitrate     = 100_000
+	maxBitrate     = 5_000_000
+)
+
+// newAPI builds the webrtc API shared by all peer connections of the SFU.
+// Every new peer connection gets its own send-side bandwidth estimator,
+// handed over on estimators.
+func newAPI(cfg config.WebRTCConfig, estimators chan<- cc.BandwidthEstimator) (*webrtc.API, error) {
+	mediaEngine, err := newMediaEngine()
+	if err != nil {
+		return nil, err
+	}
+
+	// Default interceptors handle NACK generation and responses (RTX),
+	// RTCP sender/receiver reports and TWCC feedback
+	registry := &interceptor.Registry{}
+	if err := webrtc.RegisterDefaultInterceptors(mediaEngine, registry); err != nil {
+		return nil, err
+	}
+
+	// Transport-wide sequence numbers on what we send let subscribers
+	// report arrival times, GCC estimates their bandwidth from that.
+	// The pacer is off, congestion is handled by pausing video.
+	congestionController, err := cc.NewInterceptor(func() (cc.BandwidthEstimator, error) {
+		return gcc.NewSendSideBWE(
+			gcc.SendSideBWEInitialBitrate(initialBitrate),
+			gcc.SendSideBWEMinBitrate(minBitrate),
+			gcc.SendSideBWEMaxBitrate(maxBitrate),
+			gcc.SendSideBWEPacer(gcc.NewNoOpPacer()),
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+	congestionController.OnNewPeerConnection(func(_ string, estimator cc.BandwidthEstimator) {
+		estimators <- estimator
+	})
+	registry.Add(congestionController)
+	if err := webrtc.ConfigureTWCCHeaderExtensionSender(mediaEngine, registry); err != nil {
+		return nil, err
+	}
+
+	settingEngine, err := newSettingEngine(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return webrtc.NewAPI(
+		webrtc.WithMediaEngine(mediaEngine),
+		webrtc.WithInterceptorRegistry(registry),
+		webrtc.WithSettingEngine(settingEngine),
+	), nil
+}
+
+// audioLevelExtensionID finds the id negotiated for ssrc-audio-level
+func audioLevelExtensionID(receiver *webrtc.RTPReceiver) uint8 {
+	for _, extension := range receiver.GetParameters().HeaderExtensions {
+		if extension.URI == sdp.AudioLevelURI {
+			return uint8(extension.ID)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,419 @@
+package sfu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"server/common"
+	"server/files"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"gopkg.in/hraban/opus.v2"
+)
+
+const (
+	// MediaBotUsername is the participant name of the media bot in every room
+	MediaBotUsername = "media-bot"
+	// MediaBotTrackID is the track and stream id of the bot audio
+	MediaBotTrackID = MediaBotUsername + "-media"
+	mediaBotRole    = "bot"
+	maxMediaVolume  = 2
+)
+
+// TrackSourceMedia is audio played into the call by the media bot
+const TrackSourceMedia TrackSource = "media"
+
+// Actions of call_play_media
+const (
+	MediaActionPlay   = "play"
+	MediaActionPause  = "pause"
+	MediaActionResume = "resume"
+	MediaActionStop   = "stop"
+	MediaActionVolume = "volume"
+)
+
+// States reported with media_state
+const (
+	MediaStatePlaying = "playing"
+	MediaStatePaused  = "paused"
+	MediaStateStopped = "stopped"
+)
+
+// mediaBot is a server-side participant playing a file or a tone into the
+// room. Its track is published like any other, audio mix listeners get it
+// through the mixer.
+type mediaBot struct {
+	room    *Room
+	track   *PublishedTrack
+	encoder *opus.Encoder
+	packet  []byte
+
+	mu     sync.Mutex
+	source mediaSource
+	open   openMedia
+	name   string
+	loop   bool
+	paused bool
+	volume float64
+
+	done chan struct{}
+	once sync.Once
+}
+
+func newMediaBot(room *Room) (*mediaBot, error) {
+	encoder, err := opus.NewEncoder(mixerSampleRate, mixerChannels, opus.AppAudio)
+	if err != nil {
+		return nil, err
+	}
+
+	codec := webrtc.RTPCodecCapability{
+		MimeType:  webrtc.MimeTypeOpus,
+		ClockRate: mixerSampleRate,
+		Channels:  2,
+	}
+	sample, err := webrtc.NewTrackLocalStaticSample(codec, MediaBotTrackID, MediaBotUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mediaBot{
+		room: room,
+		track: &PublishedTrack{
+			ID:       MediaBotTrackID,
+			StreamID: MediaBotUsername,
+			Owner:    MediaBotUsername,
+			Kind:     webrtc.RTPCodecTypeAudio,
+			Source:   TrackSourceMedia,
+
+			sample:        sample,
+			codec:         codec,
+			downTracks:    make(map[string]*downTrack),
+			subscriptions: make(map[string]*subscription),
+			ssrcs:         make(map[string]webrtc.SSRC),
+		},
+		encoder: encoder,
+		packet:  make([]byte, mixerMaxPacket),
+		volume:  1,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// play replaces what the bot plays, the old source is closed
+func (b *mediaBot) play(source mediaSource, open openMedia, name string, loop bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.source != nil {
+		b.source.close()
+	}
+	b.source = source
+	b.open = open
+	b.name = name
+	b.loop = loop
+	b.paused = false
+}
+
+func (b *mediaBot) setPaused(paused bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.paused = paused
+}
+
+func (b *mediaBot) setVolume(volume float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.volume = volume
+}
+
+func (b *mediaBot) run() {
+	ticker := time.NewTicker(mixerFrame)
+	defer ticker.Stop()
+	defer b.closeSource()
+
+	pcm := make([]int16, mixerFrameSamples)
+	for {
+		select {
+		case <-ticker.C:
+			if !b.sendFrame(pcm) {
+				logger.Infof("Media bot finished playing in room '%s'", b.room.ID)
+				GetManager().stopMediaBot(b.room, b, "")
+				return
+			}
+		case <-b.done:
+			return
+		}
+	}
+}
+
+func (b *mediaBot) stop() {
+	b.once.Do(func() {
+		close(b.done)
+	})
+}
+
+func (b *mediaBot) closeSource() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.source != nil {
+		b.source.close()
+		b.source = nil
+	}
+}
+
+// sendFrame plays the next 20 ms, false once the source is over.
+// Silence is sent while paused so the RTP timing stays continuous.
+func (b *mediaBot) sendFrame(pcm []int16) bool {
+	b.mu.Lock()
+	if b.paused {
+		for i := range pcm {
+			pcm[i] = 0
+		}
+	} else if !b.readFrame(pcm) {
+		b.mu.Unlock()
+		return false
+	}
+
+	n, err := b.encoder.Encode(pcm, b.packet)
+	if err != nil {
+		b.mu.Unlock()
+		logger.Warnf("Opus encode for media bot failed: %v", err)
+		return true
+	}
+	packet := append([]byte(nil), b.packet[:n]...)
+	b.mu.Unlock()
+
+	if err := b.track.sample.WriteSample(media.Sample{Data: packet, Duration: mixerFrame}); err != nil {
+		logger.Warnf("Write media bot audio failed: %v", err)
+	}
+	if mixer := b.room.audioMixer.Load(); mixer != nil {
+		mixer.push(MediaBotUsername, packet)
+	}
+	return true
+}
+
+// readFrame reads the source at the bot volume, reopening it to loop.
+// Must be called with b.mu held.
+func (b *mediaBot) readFrame(pcm []int16) bool {
+	if b.source == nil {
+		return false
+	}
+
+	err := b.source.readFrame(pcm)
+	if errors.Is(err, io.EOF) && b.loop {
+		b.source.close()
+		b.source, err = b.open()
+		if err == nil {
+			err = b.source.readFrame(pcm)
+		}
+	}
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			logger.Warnf("Media bot source %s failed: %v", b.name, err)
+		}
+		return false
+	}
+
+	if b.volume != 1 {
+		for i, sample := range pcm {
+			pcm[i] = clampSample(int32(float64(sample) * b.volume))
+		}
+	}
+	return true
+}
+
+// state is playing or paused, stopped bots are no longer in the room
+func (b *mediaBot) state() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.paused {
+		return MediaStatePaused
+	}
+	return MediaStatePlaying
+}
+
+func (b *mediaBot) stateMessage(state string, by string) *common.OutgoingMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return common.NewMessage(common.MessageTypeMediaState, common.MediaStatePayload{
+		RoomID:   b.room.ID,
+		Username: MediaBotUsername,
+		TrackID:  MediaBotTrackID,
+		State:    state,
+		Source:   b.name,
+		Volume:   b.volume,
+		Loop:     b.loop,
+		By:       by,
+	})
+}
+
+// playMedia starts the bot of the room or switches it to a new source
+func (m *Manager) playMedia(client *Client, source mediaSource, open openMedia, name string, loop bool, volume *float64) {
+	room := client.Room
+
+	bot := room.bot.Load()
+	if bot == nil {
+		var err error
+		bot, err = newMediaBot(room)
+		if err != nil {
+			source.close()
+			logger.Errorf("Не удалось создать медиабота: %v", err)
+			sendError(client.Context, "Не удалось запустить воспроизведение.")
+			return
+		}
+		if !room.bot.CompareAndSwap(nil, bot) {
+			source.close()
+			sendError(client.Context, "Воспроизведение уже запускается, попробуйте ещё раз.")
+			return
+		}
+
+		bot.play(source, open, name, loop)
+		if volume != nil {
+			bot.setVolume(*volume)
+		}
+
+		room.mu.Lock()
+		room.Tracks[bot.track.ID] = bot.track
+		room.mu.Unlock()
+		m.addTrackToOtherClients(room, bot.track)
+		m.announceTrack(room, bot.track)
+		go bot.run()
+	} else {
+		bot.play(source, open, name, loop)
+		if volume != nil {
+			bot.setVolume(*volume)
+		}
+	}
+
+	logger.Infof("'%s' включил %s в комнате '%s'", client.Username, name, room.ID)
+	room.broadcast(bot.stateMessage(MediaStatePlaying, client.Username))
+}
+
+// stopMediaBot removes the bot from the room unless it was already replaced
+// by a newer one, by is empty when the media ended
+func (m *Manager) stopMediaBot(room *Room, bot *mediaBot, by string) bool {
+	if !room.bot.CompareAndSwap(bot, nil) {
+		return false
+	}
+
+	bot.stop()
+	m.unpublishTrack(room, bot.track)
+	room.broadcast(bot.stateMessage(MediaStateStopped, by))
+	return true
+}
+
+// mediaOpener resolves what call_play_media asks to play
+func mediaOpener(request common.CallPlayMediaPayload) (openMedia, string, error) {
+	// The tone and the Morse text share the frequency
+	if request.ToneHz > mixerSampleRate/2 {
+		return nil, "", errors.New("Частота тона слишком высокая.")
+	}
+	if request.DurationSeconds < 0 {
+		return nil, "", errors.New("Длительность не может быть отрицательной.")
+	}
+
+	switch {
+	case request.File != "":
+		name := filepath.Base(request.File)
+		if name != request.File || name == "." || name == ".." {
+			return nil, "", errors.New("Файл не найден.")
+		}
+		path := filepath.Join(files.UploadDir, name)
+		return func() (mediaSource, error) {
+			return openOggSource(path)
+		}, name, nil
+
+	case request.Text != "":
+		frequency := request.ToneHz
+		if frequency <= 0 {
+			frequency = defaultToneHz
+		}
+		if _, err := newMorseSource(frequency, request.Text); err != nil {
+			return nil, "", errors.New("В тексте нет символов для азбуки Морзе.")
+		}
+		return func() (mediaSource, error) {
+			return newMorseSource(frequency, request.Text)
+		}, fmt.Sprintf("morse %.0f Hz", frequency), nil
+
+	case request.ToneHz > 0:
+		return func() (mediaSource, error) {
+			return newToneSource(request.ToneHz, request.DurationSeconds), nil
+		}, fmt.Sprintf("tone %.0f Hz", request.ToneHz), nil
+
+	default:
+		return nil, "", errors.New("Укажите файл, тон или текст.")
+	}
+}
+
+// HandleCallPlayMedia controls the media bot of the moderator call
+func HandleCallPlayMedia(context common.ClientContext, payload common.Payload) {
+	client, ok := callModerator(context, "Недостаточно прав для воспроизведения в звонке.")
+	if !ok {
+		return
+	}
+
+	var request common.CallPlayMediaPayload
+	if err := payload.Decode(&request); err != nil {
+		sendError(context, "Некорректные данные для команды call_play_media.")
+		return
+	}
+	if request.Volume != nil && (*request.Volume < 0 || *request.Volume > maxMediaVolume) {
+		sendError(context, "Громкость должна быть от 0 до 2.")
+		return
+	}
+
+	m := GetManager()
+	room := client.Room
+
+	if request.Action == MediaActionPlay {
+		open, name, err := mediaOpener(request)
+		if err != nil {
+			sendError(context, err.Error())
+			return
+		}
+		if request.File != "" && !files.CanAccess(context.GetUsername(), context.GetRole(), name) {
+			sendError(context, "Файл не найден.")
+			return
+		}
+		source, err := open()
+		if err != nil {
+			logger.Warnf("Media bot can't open %s: %v", name, err)
+			sendError(context, "Не удалось открыть файл, нужен Ogg/Opus.")
+			return
+		}
+		m.playMedia(client, source, open, name, request.Loop, request.Volume)
+		return
+	}
+
+	bot := room.bot.Load()
+	if bot == nil {
+		sendError(context, "Сейчас ничего не воспроизводится.")
+		return
+	}
+
+	switch request.Action {
+	case MediaActionPause, MediaActionResume:
+		bot.setPaused(request.Action == MediaActionPause)
+		room.broadcast(bot.stateMessage(bot.state(), client.Username))
+
+	case MediaActionVolume:
+		if request.Volume == nil {
+			sendError(context, "Не указана громкость.")
+			return
+		}
+		bot.setVolume(*request.Volume)
+		room.broadcast(bot.stateMessage(bot.state(), client.Username))
+
+	case MediaActionStop:
+		if m.stopMediaBot(room, bot, client.Username) {
+			logger.Infof("'%s' остановил воспроизведение в комнате '%s'", client.Username, room.ID)
+		}
+
+	default:
+		sendError(context, "Неизвестное действие для call_play_media.")
+	}
+}
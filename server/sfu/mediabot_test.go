@@ -0,0 +1,51 @@
+package sfu
+
+import (
+	"server/common"
+	"testing"
+)
+
+func TestMediaOpenerValidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		request common.CallPlayMediaPayload
+		err     string
+	}{
+		{name: "tone", request: common.CallPlayMediaPayload{ToneHz: 440, DurationSeconds: 2}},
+		{name: "morse with default frequency", request: common.CallPlayMediaPayload{Text: "sos"}},
+		{
+			name:    "tone above nyquist",
+			request: common.CallPlayMediaPayload{ToneHz: mixerSampleRate},
+			err:     "Частота тона слишком высокая.",
+		},
+		{
+			name:    "morse above nyquist",
+			request: common.CallPlayMediaPayload{Text: "sos", ToneHz: mixerSampleRate},
+			err:     "Частота тона слишком высокая.",
+		},
+		{
+			name:    "negative duration",
+			request: common.CallPlayMediaPayload{ToneHz: 440, DurationSeconds: -1},
+			err:     "Длительность не может быть отрицательной.",
+		},
+		{
+			name:    "file outside uploads",
+			request: common.CallPlayMediaPayload{File: "../chat.db"},
+			err:     "Файл не найден.",
+		},
+		{name: "nothing to play", err: "Укажите файл, тон или текст."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := mediaOpener(test.request)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != test.err {
+				t.Errorf("error = %q, want %q", got, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package sfu
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"gopkg.in/hraban/opus.v2"
+)
+
+const (
+	// toneAmplitude keeps the test tone well below clipping
+	toneAmplitude = 0.3 * 32767
+	// defaultToneHz is used for Morse text without a frequency
+	defaultToneHz = 440
+	// morseUnitSamples is one Morse dot, 60 ms or about 20 words per minute
+	morseUnitSamples = mixerSampleRate * 60 / 1000
+)
+
+// mediaSource produces the audio played by the media bot
+type mediaSource interface {
+	// readFrame fills one frame of mono 48 kHz audio, io.EOF at the end
+	readFrame(pcm []int16) error
+	close()
+}
+
+// openMedia opens a new source, it is called again to loop
+type openMedia func() (mediaSource, error)
+
+// oggPacketReader splits the pages of an Ogg stream into packets (RFC 3533).
+// Only the first logical stream is expected.
+type oggPacketReader struct {
+	r        io.Reader
+	segments []byte
+	data     []byte
+	// packet is continued on the next page
+	packet []byte
+}
+
+func (o *oggPacketReader) next() ([]byte, error) {
+	for {
+		for len(o.segments) > 0 {
+			size := int(o.segments[0])
+			o.segments = o.segments[1:]
+			o.packet = append(o.packet, o.data[:size]...)
+			o.data = o.data[size:]
+			// A lacing value below 255 ends the packet
+			if size < 255 {
+				packet := o.packet
+				o.packet = nil
+				return packet, nil
+			}
+		}
+		if err := o.readPage(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (o *oggPacketReader) readPage() error {
+	header := make([]byte, 27)
+	if _, err := io.ReadFull(o.r, header); err != nil {
+		return err
+	}
+	if string(header[:4]) != "OggS" {
+		return errors.New("not an ogg page")
+	}
+
+	segments := make([]byte, header[26])
+	if _, err := io.ReadFull(o.r, segments); err != nil {
+		return err
+	}
+	size := 0
+	for _, lacing := range segments {
+		size += int(lacing)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(o.r, data); err != nil {
+		return err
+	}
+
+	// Without the continuation flag a partial packet can't be completed
+	if header[5]&0x01 == 0 {
+		o.packet = nil
+	}
+	o.segments = segments
+	o.data = data
+	return nil
+}
+
+// oggSource decodes an Ogg/Opus file, stereo is downmixed
+type oggSource struct {
+	file     *os.File
+	reader   *oggPacketReader
+	decoder  *opus.Decoder
+	channels int
+	decoded  []int16
+	samples  []int16
+}
+
+func openOggSource(path string) (mediaSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	source := &oggSource{
+		file:   file,
+		reader: &oggPacketReader{r: bufio.NewReader(file)},
+	}
+
+	// OpusHead then OpusTags come before the audio (RFC 7845)
+	head, err := source.reader.next()
+	if err != nil || len(head) < 19 || !bytes.HasPrefix(head, []byte("OpusHead")) {
+		file.Close()
+		return nil, errors.New("not an ogg/opus file")
+	}
+	if _, err := source.reader.next(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	source.channels = int(head[9])
+	if source.channels != 1 && source.channels != 2 {
+		file.Close()
+		return nil, errors.New("only mono and stereo opus is supported")
+	}
+	source.decoder, err = opus.NewDecoder(mixerSampleRate, source.channels)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	// 120 ms is the longest Opus packet
+	source.decoded = make([]int16, 6*mixerFrameSamples*source.channels)
+	return source, nil
+}
+
+func (s *oggSource) readFrame(pcm []int16) error {
+	for len(s.samples) < len(pcm) {
+		packet, err := s.reader.next()
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			err = io.EOF
+		}
+		if err != nil {
+			return err
+		}
+
+		n, err := s.decoder.Decode(packet, s.decoded)
+		if err != nil {
+			logger.Debugf("Opus decode of %s failed: %v", s.file.Name(), err)
+			continue
+		}
+		for i := 0; i < n; i++ {
+			if s.channels == 2 {
+				s.samples = append(s.samples, int16((int32(s.decoded[2*i])+int32(s.decoded[2*i+1]))/2))
+			} else {
+				s.samples = append(s.samples, s.decoded[i])
+			}
+		}
+	}
+
+	copy(pcm, s.samples)
+	s.samples = s.samples[len(pcm):]
+	return nil
+}
+
+func (s *oggSource) close() {
+	s.file.Close()
+}
+
+// toneSource is a sine test signal, keyed as Morse code when keying is set
+type toneSource struct {
+	frequency float64
+	// total samples to play, 0 plays until stopped
+	total    int
+	position int
+	// keying tells for every Morse unit whether the tone is on
+	keying []bool
+}
+
+func newToneSource(frequency float64, seconds float64) *toneSource {
+	return &toneSource{
+		frequency: frequency,
+		total:     int(seconds * mixerSampleRate),
+	}
+}
+
+func newMorseSource(frequency float64, text string) (*toneSource, error) {
+	keying := morseKeying(text)
+	if len(keying) == 0 {
+		return nil, errors.New("nothing to key")
+	}
+	return &toneSource{
+		frequency: frequency,
+		total:     len(keying) * morseUnitSamples,
+		keying:    keying,
+	}, nil
+}
+
+func (s *toneSource) readFrame(pcm []int16) error {
+	if s.total > 0 && s.position >= s.total {
+		return io.EOF
+	}
+
+	for i := range pcm {
+		on := s.keying == nil
+		if unit := s.position / morseUnitSamples; unit < len(s.keying) {
+			on = s.keying[unit]
+		}
+		pcm[i] = 0
+		if on {
+			phase := 2 * math.Pi * s.frequency * float64(s.position) / mixerSampleRate
+			pcm[i] = int16(toneAmplitude * math.Sin(phase))
+		}
+		s.position++
+	}
+	return nil
+}
+
+func (s *toneSource) close() {}
+
+var morseCode = map[rune]string{
+	'A': ".-", 'B': "-...", 'C': "-.-.", 'D': "-..", 'E': ".", 'F': "..-.",
+	'G': "--.", 'H': "....", 'I': "..", 'J': ".---", 'K': "-.-", 'L': ".-..",
+	'M': "--", 'N': "-.", 'O': "---", 'P': ".--.", 'Q': "--.-", 'R': ".-.",
+	'S': "...", 'T': "-", 'U': "..-", 'V': "...-", 'W': ".--", 'X': "-..-",
+	'Y': "-.--", 'Z': "--..",
+
+	'А': ".-", 'Б': "-...", 'В': ".--", 'Г': "--.", 'Д': "-..", 'Е': ".",
+	'Ё': ".", 'Ж': "...-", 'З': "--..", 'И': "..", 'Й': ".---", 'К': "-.-",
+	'Л': ".-..", 'М': "--", 'Н': "-.", 'О': "---", 'П': ".--.", 'Р': ".-.",
+	'С': "...", 'Т': "-", 'У': "..-", 'Ф': "..-.", 'Х': "....", 'Ц': "-.-.",
+	'Ч': "---.", 'Ш': "----", 'Щ': "--.-", 'Ъ': "--.--", 'Ы': "-.--", 'Ь': "-..-",
+	'Э': "..-..", 'Ю': "..--", 'Я': ".-.-",
+
+	'0': "-----", '1': ".----", '2': "..---", '3': "...--", '4': "....-",
+	'5': ".....", '6': "-....", '7': "--...", '8': "---..", '9': "----.",
+}
+
+// morseKeying turns text into on/off units: a dot is one unit, a dash
+// three, with one unit between symbols, three between letters and seven
+// between words. Unknown characters are skipped.
+func morseKeying(text string) []bool {
+	var keying []bool
+	key := func(on bool, units int) {
+		for i := 0; i < units; i++ {
+			keying = append(keying, on)
+		}
+	}
+
+	for _, word := range strings.Fields(strings.ToUpper(text)) {
+		letters := 0
+		for _, letter := range word {
+			code, ok := morseCode[letter]
+			if !ok {
+				continue
+			}
+			switch {
+			case letters > 0:
+				key(false, 3)
+			case len(keying) > 0:
+				key(false, 7)
+			}
+			letters++
+
+			for i, symbol := range code {
+				if i > 0 {
+					key(false, 1)
+				}
+				if symbol == '-' {
+					key(true, 3)
+				} else {
+					key(true, 1)
+				}
+			}
+		}
+	}
+	return keying
+}
@@ -0,0 +1,143 @@
+package sfu
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// oggPage builds a page of the first logical stream with the given lacing
+// values, continued sets the flag for a packet carried over from the last page
+func oggPage(continued bool, lacing []byte, data []byte) []byte {
+	header := make([]byte, 27)
+	copy(header, "OggS")
+	if continued {
+		header[5] = 0x01
+	}
+	header[26] = byte(len(lacing))
+	page := append(header, lacing...)
+	return append(page, data...)
+}
+
+func TestOggPacketReader(t *testing.T) {
+	long := bytes.Repeat([]byte{'l'}, 255)
+	longer := bytes.Repeat([]byte{'m'}, 300)
+
+	tests := []struct {
+		name    string
+		stream  [][]byte
+		packets []string
+		// err ends the stream, io.EOF when empty
+		err string
+	}{
+		{
+			name:    "one packet",
+			stream:  [][]byte{oggPage(false, []byte{3}, []byte("abc"))},
+			packets: []string{"abc"},
+		},
+		{
+			name:    "two packets on a page",
+			stream:  [][]byte{oggPage(false, []byte{2, 1}, []byte("abc"))},
+			packets: []string{"ab", "c"},
+		},
+		{
+			name:    "255 bytes end with an empty segment",
+			stream:  [][]byte{oggPage(false, []byte{255, 0, 1}, append(append([]byte{}, long...), 'x'))},
+			packets: []string{string(long), "x"},
+		},
+		{
+			name: "packet continued on the next page",
+			stream: [][]byte{
+				oggPage(false, []byte{255}, longer[:255]),
+				oggPage(true, []byte{45}, longer[255:]),
+			},
+			packets: []string{string(longer)},
+		},
+		{
+			name: "partial packet without continuation is dropped",
+			stream: [][]byte{
+				oggPage(false, []byte{255}, long),
+				oggPage(false, []byte{1}, []byte("y")),
+			},
+			packets: []string{"y"},
+		},
+		{
+			name:   "not an ogg page",
+			stream: [][]byte{[]byte(strings.Repeat("x", 27))},
+			err:    "not an ogg page",
+		},
+		{
+			name:   "truncated page",
+			stream: [][]byte{oggPage(false, []byte{3}, []byte("ab"))},
+			err:    io.ErrUnexpectedEOF.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := &oggPacketReader{r: bytes.NewReader(bytes.Join(test.stream, nil))}
+
+			var packets []string
+			var err error
+			for {
+				var packet []byte
+				packet, err = reader.next()
+				if err != nil {
+					break
+				}
+				packets = append(packets, string(packet))
+			}
+
+			if !reflect.DeepEqual(packets, test.packets) {
+				t.Errorf("packets = %q, want %q", packets, test.packets)
+			}
+			want := test.err
+			if want == "" {
+				want = io.EOF.Error()
+			}
+			if err.Error() != want {
+				t.Errorf("error = %v, want %v", err, want)
+			}
+		})
+	}
+}
+
+// keyingString shows keying as = for tone and . for silence
+func keyingString(keying []bool) string {
+	var b strings.Builder
+	for _, on := range keying {
+		if on {
+			b.WriteByte('=')
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
+
+func TestMorseKeying(t *testing.T) {
+	tests := []struct {
+		text   string
+		keying string
+	}{
+		{text: "e", keying: "="},
+		{text: "T", keying: "==="},
+		{text: "A", keying: "=.==="},
+		{text: "EE", keying: "=...="},
+		{text: "E E", keying: "=.......="},
+		{text: "  E\tT  ", keying: "=.......==="},
+		{text: "Ё", keying: "="},
+		{text: "E?T", keying: "=...==="},
+		{text: "? T", keying: "==="},
+		{text: "?!", keying: ""},
+		{text: "", keying: ""},
+	}
+
+	for _, test := range tests {
+		if got := keyingString(morseKeying(test.text)); got != test.keying {
+			t.Errorf("morseKeying(%q) = %q, want %q", test.text, got, test.keying)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package sfu
+
+import (
+	"server/common"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"gopkg.in/hraban/opus.v2"
+)
+
+const (
+	mixerSampleRate = 48000
+	mixerChannels   = 1
+	mixerFrame      = 20 * time.Millisecond
+	// mixerFrameSamples is one mixed frame, 20 ms of mono 48 kHz audio
+	mixerFrameSamples = mixerSampleRate / 1000 * int(mixerFrame/time.Millisecond)
+	// mixerMaxBuffered drops the oldest audio of an input lagging behind
+	mixerMaxBuffered = 10 * mixerFrameSamples
+	// mixerMaxPacket is the largest Opus packet we encode
+	mixerMaxPacket = 1500
+
+	// MixTrackID is the track and stream id of the mixed audio
+	MixTrackID = "mix"
+)
+
+// TrackSourceMix is the mixed audio of the room
+const TrackSourceMix TrackSource = "mix"
+
+// mixerInput is the decoded audio of one publisher waiting to be mixed
+type mixerInput struct {
+	decoder *opus.Decoder
+	samples []int16
+}
+
+// mixerOutput is one listener receiving everybody but themselves
+type mixerOutput struct {
+	track   *webrtc.TrackLocalStaticSample
+	encoder *opus.Encoder
+	packet  []byte
+}
+
+// audioMixer decodes the room audio, mixes it minus each listener's own
+// voice and sends the result as a single Opus track
+type audioMixer struct {
+	room *Room
+
+	mu      sync.Mutex
+	inputs  map[string]*mixerInput
+	outputs map[string]*mixerOutput
+	// stopped is set when the last listener left, the room starts a new
+	// mixer for the next one
+	stopped bool
+
+	done chan struct{}
+	once sync.Once
+}
+
+func newAudioMixer(room *Room) *audioMixer {
+	return &audioMixer{
+		room:    room,
+		inputs:  make(map[string]*mixerInput),
+		outputs: make(map[string]*mixerOutput),
+		done:    make(chan struct{}),
+	}
+}
+
+// mixer returns the room mixer, starting it on first use
+func (r *Room) mixer() *audioMixer {
+	if mixer := r.audioMixer.Load(); mixer != nil {
+		return mixer
+	}
+	mixer := newAudioMixer(r)
+	if !r.audioMixer.CompareAndSwap(nil, mixer) {
+		return r.audioMixer.Load()
+	}
+	logger.Infof("Audio mixer started in room '%s'", r.ID)
+	go mixer.run()
+	return mixer
+}
+
+// addMixListener sends the mixed audio to the client instead of separate tracks
+func (r *Room) addMixListener(client *Client) error {
+	encoder, err := opus.NewEncoder(mixerSampleRate, mixerChannels, opus.AppVoIP)
+	if err != nil {
+		return err
+	}
+
+	track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{
+		MimeType:  webrtc.MimeTypeOpus,
+		ClockRate: mixerSampleRate,
+		Channels:  2,
+	}, MixTrackID, MixTrackID)
+	if err != nil {
+		return err
+	}
+
+	sender, err := client.PeerConnection.AddTrack(track)
+	if err != nil {
+		return err
+	}
+	go discardRTCP(sender)
+
+	output := &mixerOutput{
+		track:   track,
+		encoder: encoder,
+		packet:  make([]byte, mixerMaxPacket),
+	}
+	// The mixer may stop between loading and attaching when its last
+	// listener leaves
+	for !r.mixer().attach(client.Username, output) {
+	}
+
+	client.Context.Send(mixTrackPublished())
+	return nil
+}
+
+// attach adds a listener output, false if the mixer is already stopped
+func (m *audioMixer) attach(username string, output *mixerOutput) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped {
+		return false
+	}
+	m.outputs[username] = output
+	return true
+}
+
+// mixTrackPublished announces the mixed audio track to a listener
+func mixTrackPublished() *common.OutgoingMessage {
+	return common.NewMessage(common.MessageTypeTrackPublished, common.TrackPublishedPayload{
+		TrackID:  MixTrackID,
+		StreamID: MixTrackID,
+		Kind:     webrtc.RTPCodecTypeAudio.String(),
+		Source:   string(TrackSourceMix),
+	})
+}
+
+// removeListener stops the mixer once nobody listens to it
+func (m *audioMixer) removeListener(username string) {
+	m.mu.Lock()
+	delete(m.outputs, username)
+	if len(m.outputs) > 0 || m.stopped {
+		m.mu.Unlock()
+		return
+	}
+	m.stopped = true
+	m.mu.Unlock()
+
+	m.stop()
+	if m.room.audioMixer.CompareAndSwap(m, nil) {
+		logger.Infof("Audio mixer stopped in room '%s'", m.room.ID)
+	}
+}
+
+// push decodes an Opus packet of a publisher
+func (m *audioMixer) push(username string, payload []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.outputs) == 0 {
+		return
+	}
+
+	input, ok := m.inputs[username]
+	if !ok {
+		decoder, err := opus.NewDecoder(mixerSampleRate, mixerChannels)
+		if err != nil {
+			logger.Errorf("Opus decoder for %s failed: %v", username, err)
+			return
+		}
+		input = &mixerInput{decoder: decoder}
+		m.inputs[username] = input
+	}
+
+	// 120 ms is the longest Opus packet
+	pcm := make([]int16, 6*mixerFrameSamples)
+	n, err := input.decoder.Decode(payload, pcm)
+	if err != nil {
+		logger.Debugf("Opus decode for %s failed: %v", username, err)
+		return
+	}
+
+	input.samples = append(input.samples, pcm[:n]...)
+	if extra := len(input.samples) - mixerMaxBuffered; extra > 0 {
+		input.samples = input.samples[extra:]
+	}
+}
+
+// removeInput forgets a publisher whose audio track ended
+func (m *audioMixer) removeInput(username string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.inputs, username)
+}
+
+func (m *audioMixer) run() {
+	ticker := time.NewTicker(mixerFrame)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.mix()
+		case <-m.done:
+			return
+		}
+	}
+}
+
+func (m *audioMixer) stop() {
+	m.once.Do(func() {
+		close(m.done)
+	})
+}
+
+// mixedFrame is the audio one listener gets for the current frame
+type mixedFrame struct {
+	username string
+	output   *mixerOutput
+	pcm      []int16
+}
+
+// mix sends one frame to every listener. Encoding and writing happen
+// outside the lock, only this goroutine uses the encoders.
+func (m *audioMixer) mix() {
+	for _, frame := range m.nextFrames() {
+		output := frame.output
+		n, err := output.encoder.Encode(frame.pcm, output.packet)
+		if err != nil {
+			logger.Warnf("Opus encode for %s failed: %v", frame.username, err)
+			continue
+		}
+		sample := media.Sample{Data: append([]byte(nil), output.packet[:n]...), Duration: mixerFrame}
+		if err := output.track.WriteSample(sample); err != nil {
+			logger.Warnf("Write mixed audio to %s failed: %v", frame.username, err)
+		}
+	}
+}
+
+// nextFrames takes one frame from every input and mixes it for each
+// listener without their own voice. Inputs that have nothing buffered are
+// silent for this frame.
+func (m *audioMixer) nextFrames() []mixedFrame {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.outputs) == 0 {
+		return nil
+	}
+
+	frames := make(map[string][]int16, len(m.inputs))
+	total := make([]int32, mixerFrameSamples)
+	for username, input := range m.inputs {
+		if len(input.samples) < mixerFrameSamples {
+			continue
+		}
+		frame := input.samples[:mixerFrameSamples]
+		input.samples = input.samples[mixerFrameSamples:]
+		frames[username] = frame
+		for i, sample := range frame {
+			total[i] += int32(sample)
+		}
+	}
+
+	mixed := make([]mixedFrame, 0, len(m.outputs))
+	for username, output := range m.outputs {
+		own := frames[username]
+		pcm := make([]int16, mixerFrameSamples)
+		for i := range pcm {
+			sample := total[i]
+			if own != nil {
+				sample -= int32(own[i])
+			}
+			pcm[i] = clampSample(sample)
+		}
+		mixed = append(mixed, mixedFrame{username: username, output: output, pcm: pcm})
+	}
+	return mixed
+}
+
+func clampSample(sample int32) int16 {
+	switch {
+	case sample > 32767:
+		return 32767
+	case sample < -32768:
+		return -32768
+	default:
+		return int16(sample)
+	}
+}
+
+// discardRTCP drains a sender so the interceptors keep working
+func discardRTCP(sender *webrtc.RTPSender) {
+	buf := make([]byte, 1500)
+	for {
+		if _, _, err := sender.Read(buf); err != nil {
+			return
+		}
+	}
+}
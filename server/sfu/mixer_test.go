@@ -0,0 +1,139 @@
+package sfu
+
+import (
+	"math"
+	"testing"
+
+	"gopkg.in/hraban/opus.v2"
+)
+
+// sineSpeaker encodes a continuous tone into 20 ms Opus frames
+type sineSpeaker struct {
+	username  string
+	frequency float64
+	encoder   *opus.Encoder
+	position  int
+}
+
+func newSineSpeaker(t *testing.T, username string, frequency float64) *sineSpeaker {
+	t.Helper()
+
+	encoder, err := opus.NewEncoder(mixerSampleRate, mixerChannels, opus.AppVoIP)
+	if err != nil {
+		t.Fatalf("new encoder: %v", err)
+	}
+	return &sineSpeaker{username: username, frequency: frequency, encoder: encoder}
+}
+
+func (s *sineSpeaker) nextPacket(t *testing.T) []byte {
+	t.Helper()
+
+	pcm := make([]int16, mixerFrameSamples)
+	for i := range pcm {
+		phase := 2 * math.Pi * s.frequency * float64(s.position+i) / mixerSampleRate
+		pcm[i] = int16(toneAmplitude * math.Sin(phase))
+	}
+	s.position += len(pcm)
+
+	packet := make([]byte, mixerMaxPacket)
+	n, err := s.encoder.Encode(pcm, packet)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return packet[:n]
+}
+
+// tonePower is the Goertzel power of one frequency in the samples
+func tonePower(pcm []int16, frequency float64) float64 {
+	coefficient := 2 * math.Cos(2*math.Pi*frequency/mixerSampleRate)
+	var previous, beforePrevious float64
+	for _, sample := range pcm {
+		current := float64(sample) + coefficient*previous - beforePrevious
+		beforePrevious, previous = previous, current
+	}
+	return previous*previous + beforePrevious*beforePrevious - coefficient*previous*beforePrevious
+}
+
+func TestMixerExcludesOwnVoice(t *testing.T) {
+	speakers := []*sineSpeaker{
+		newSineSpeaker(t, "alice", 440),
+		newSineSpeaker(t, "bob", 1000),
+	}
+
+	mixer := newAudioMixer(nil)
+	for _, username := range []string{"alice", "bob", "carol"} {
+		mixer.outputs[username] = &mixerOutput{}
+	}
+
+	tests := []struct {
+		listener string
+		hears    []float64
+		silent   []float64
+	}{
+		{listener: "alice", hears: []float64{1000}, silent: []float64{440}},
+		{listener: "bob", hears: []float64{440}, silent: []float64{1000}},
+		{listener: "carol", hears: []float64{440, 1000}},
+	}
+
+	// The first frames carry the decoder warm-up, check a later one
+	var frames []mixedFrame
+	for range 10 {
+		for _, speaker := range speakers {
+			mixer.push(speaker.username, speaker.nextPacket(t))
+		}
+		frames = mixer.nextFrames()
+	}
+
+	// Goertzel power of a sent tone, the codec may lose some of it
+	sentPower := math.Pow(toneAmplitude*float64(mixerFrameSamples)/2, 2)
+	minPower := sentPower / 4
+
+	pcm := make(map[string][]int16, len(frames))
+	for _, frame := range frames {
+		pcm[frame.username] = frame.pcm
+	}
+
+	for _, test := range tests {
+		t.Run(test.listener, func(t *testing.T) {
+			frame, ok := pcm[test.listener]
+			if !ok {
+				t.Fatalf("no frame mixed for %s", test.listener)
+			}
+			for _, heard := range test.hears {
+				heardPower := tonePower(frame, heard)
+				if heardPower < minPower {
+					t.Errorf("%s hears %v Hz at %.3f of the sent power", test.listener, heard, heardPower/sentPower)
+				}
+				for _, silent := range test.silent {
+					if ratio := tonePower(frame, silent) / heardPower; ratio > 0.01 {
+						t.Errorf("%s hears its own %v Hz at %.3f of the %v Hz tone", test.listener, silent, ratio, heard)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMixerStopsWithoutListeners(t *testing.T) {
+	room := &Room{ID: "mixer-stop"}
+	mixer := room.mixer()
+	if !mixer.attach("alice", &mixerOutput{}) {
+		t.Fatal("a running mixer refused a listener")
+	}
+
+	mixer.removeListener("alice")
+
+	select {
+	case <-mixer.done:
+	default:
+		t.Fatal("the mixer kept running without listeners")
+	}
+	if mixer.attach("bob", &mixerOutput{}) {
+		t.Error("a stopped mixer accepted a listener")
+	}
+	next := room.mixer()
+	if next == mixer {
+		t.Error("the stopped mixer is still used by the room")
+	}
+	next.stop()
+}
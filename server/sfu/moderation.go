@@ -0,0 +1,133 @@
+package sfu
+
+import (
+	"server/common"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// HandleMuteParticipant stops forwarding the audio or video of a participant
+func HandleMuteParticipant(context common.ClientContext, payload common.Payload) {
+	moderator, target, request, ok := moderationTarget(context, payload)
+	if !ok {
+		return
+	}
+
+	kind := webrtc.NewRTPCodecType(request.Kind)
+	if kind == 0 {
+		sendError(context, "Можно выключить только 'audio' или 'video'.")
+		return
+	}
+
+	target.setMuted(kind, true)
+	logger.Infof("'%s' выключил %s у '%s'", moderator.Username, kind, target.Username)
+
+	moderator.Room.broadcast(common.NewMessage(common.MessageTypeParticipantMuted, common.ParticipantMutedPayload{
+		Username: target.Username,
+		Kind:     kind.String(),
+		Muted:    true,
+		By:       moderator.Username,
+	}))
+}
+
+// HandleRequestUnmute lifts a server-side mute and asks the participant
+// to turn the device back on. The server can't unmute a muted microphone.
+func HandleRequestUnmute(context common.ClientContext, payload common.Payload) {
+	moderator, target, request, ok := moderationTarget(context, payload)
+	if !ok {
+		return
+	}
+
+	kind := webrtc.NewRTPCodecType(request.Kind)
+	if kind == 0 {
+		sendError(context, "Можно включить только 'audio' или 'video'.")
+		return
+	}
+
+	target.setMuted(kind, false)
+	logger.Infof("'%s' просит '%s' включить %s", moderator.Username, target.Username, kind)
+
+	state := common.ParticipantMutedPayload{
+		Username: target.Username,
+		Kind:     kind.String(),
+		Muted:    false,
+		By:       moderator.Username,
+	}
+	moderator.Room.broadcast(common.NewMessage(common.MessageTypeParticipantMuted, state))
+	target.Context.Send(common.NewMessage(common.MessageTypeCallRequestUnmute, state))
+}
+
+// HandleRemoveParticipant drops a participant from the call.
+// The websocket stays open, the participant can still chat.
+func HandleRemoveParticipant(context common.ClientContext, payload common.Payload) {
+	moderator, target, _, ok := moderationTarget(context, payload)
+	if !ok {
+		return
+	}
+
+	logger.Infof("'%s' удалил '%s' из звонка '%s'", moderator.Username, target.Username, moderator.Room.ID)
+
+	// Announce first so the removed participant gets it too
+	moderator.Room.broadcast(common.NewMessage(common.MessageTypeParticipantRemoved, common.ParticipantRemovedPayload{
+		Username: target.Username,
+		By:       moderator.Username,
+	}))
+	GetManager().RemoveClient(target.Username)
+}
+
+// moderationTarget resolves and permission-checks a moderation request.
+// Errors are reported to the moderator.
+func moderationTarget(context common.ClientContext, payload common.Payload) (*Client, *Client, common.CallParticipantPayload, bool) {
+	var request common.CallParticipantPayload
+	if err := payload.Decode(&request); err != nil {
+		sendError(context, "Некорректные данные для управления участником.")
+		return nil, nil, request, false
+	}
+
+	m := GetManager()
+	m.mu.RLock()
+	moderator, moderatorOk := m.Clients[context.GetUsername()]
+	target, targetOk := m.Clients[request.Username]
+	m.mu.RUnlock()
+
+	if !moderatorOk {
+		sendError(context, "Сначала нужно войти в звонок.")
+		return nil, nil, request, false
+	}
+	if !targetOk || target.Room != moderator.Room {
+		sendError(context, "Участник не найден в звонке.")
+		return nil, nil, request, false
+	}
+	if !canModerate(context.GetRole(), target.Context.GetRole()) {
+		sendError(context, "Недостаточно прав для управления участником.")
+		return nil, nil, request, false
+	}
+
+	return moderator, target, request, true
+}
+
+func (c *Client) isMuted(kind webrtc.RTPCodecType) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.muted[kind]
+}
+
+// setMuted updates the mute state and the tracks already published
+func (c *Client) setMuted(kind webrtc.RTPCodecType, muted bool) {
+	c.mu.Lock()
+	c.muted[kind] = muted
+	trackIDs := append([]string(nil), c.TrackIDs...)
+	c.mu.Unlock()
+
+	for _, trackID := range trackIDs {
+		track, ok := c.Room.track(trackID)
+		if !ok || track.Kind != kind {
+			continue
+		}
+		track.muted.Store(muted)
+		if !muted {
+			// Subscribers can't decode video until the next keyframe
+			track.RequestKeyframe()
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package sfu
+
+import (
+	"server/common"
+
+	"github.com/pion/webrtc/v4"
+)
+
+func (manager *Manager) setupNegotiationHandler(client *Client) {
+	client.PeerConnection.OnNegotiationNeeded(func() {
+		logger.Infof(
+			"Negotiation needed for %s. Current signaling state: %s",
+			client.Username,
+			client.PeerConnection.SignalingState(),
+		)
+		client.negotiate()
+	})
+}
+
+// negotiate sends a new offer to the client. If an exchange is already in
+// flight the renegotiation is queued and runs when it completes.
+func (c *Client) negotiate() {
+	c.negotiationMu.Lock()
+	defer c.negotiationMu.Unlock()
+
+	if c.static || c.PeerConnection.ConnectionState() == webrtc.PeerConnectionStateClosed {
+		return
+	}
+
+	// The offer would be lost, resume renegotiates
+	if !c.signaling.isAttached() {
+		logger.Debugf("Renegotiation for detached %s queued", c.Username)
+		c.negotiationPending = true
+		return
+	}
+
+	if c.PeerConnection.SignalingState() != webrtc.SignalingStateStable {
+		logger.Debugf("Renegotiation for %s queued (state=%s)", c.Username, c.PeerConnection.SignalingState())
+		c.negotiationPending = true
+		return
+	}
+	c.negotiationPending = false
+
+	offer, err := c.PeerConnection.CreateOffer(&webrtc.OfferOptions{ICERestart: c.iceRestart})
+	if err != nil {
+		logger.Errorf("Failed to create offer for %s: %v", c.Username, err)
+		return
+	}
+	c.iceRestart = false
+
+	if err := c.setLocalDescription(offer); err != nil {
+		logger.Errorf("Failed to set local description for %s: %v", c.Username, err)
+		return
+	}
+
+	c.negotiationID++
+	logger.Tracef("Negotiation offer #%d for %s", c.negotiationID, c.Username)
+
+	offerPayload := sdpPayload(c.PeerConnection.LocalDescription())
+	offerPayload.NegotiationID = c.negotiationID
+	c.Context.Send(common.NewMessage(common.MessageTypeSdpOffer, offerPayload))
+}
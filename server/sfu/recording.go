@@ -0,0 +1,364 @@
+package sfu
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"server/common"
+	"server/database"
+	"server/files"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media/h264writer"
+	"github.com/pion/webrtc/v4/pkg/media/ivfwriter"
+	"github.com/pion/webrtc/v4/pkg/media/oggwriter"
+)
+
+const (
+	manifestFileName = "manifest.json"
+	// recordingQueueSize is how many packets a track file may lag behind
+	// the forwarding before packets are dropped from the recording
+	recordingQueueSize = 512
+)
+
+// mediaWriter is implemented by the pion media writers
+type mediaWriter interface {
+	WriteRTP(packet *rtp.Packet) error
+	Close() error
+}
+
+// recordedTrack is one published track written to its own file
+type recordedTrack struct {
+	Username string `json:"username"`
+	TrackID  string `json:"track_id"`
+	Kind     string `json:"kind"`
+	Source   string `json:"source"`
+	Codec    string `json:"codec"`
+	File     string `json:"file"`
+	// OffsetMs is when the first packet arrived, relative to the recording start
+	OffsetMs   int64 `json:"offset_ms"`
+	DurationMs int64 `json:"duration_ms"`
+
+	layer     string
+	writer    mediaWriter
+	startedAt time.Time
+	// packets feeds the goroutine writing the file
+	packets chan *rtp.Packet
+	dropped int
+}
+
+type recordingManifest struct {
+	ID        string           `json:"id"`
+	RoomID    string           `json:"room_id"`
+	StartedBy string           `json:"started_by"`
+	StartedAt time.Time        `json:"started_at"`
+	EndedAt   time.Time        `json:"ended_at"`
+	Tracks    []*recordedTrack `json:"tracks"`
+}
+
+// recorder taps the packets forwarded in a room and writes them to disk
+type recorder struct {
+	ID        string
+	RoomID    string
+	StartedBy string
+	StartedAt time.Time
+	// dir is relative to files.UploadDir so it maps to a download url
+	dir string
+
+	mu     sync.Mutex
+	tracks map[*PublishedTrack]*recordedTrack
+	// ended are the unpublished tracks, packets still in flight for them
+	// mustn't open another file
+	ended    map[*PublishedTrack]bool
+	finished []*recordedTrack
+	stopped  bool
+	// writers tracks the file goroutines so stop can wait for them
+	writers sync.WaitGroup
+}
+
+func newRecorder(room *Room, startedBy string) (*recorder, error) {
+	id := uuid.New().String()
+	dir := filepath.Join(files.RecordingsDir, id)
+	if err := os.MkdirAll(filepath.Join(files.UploadDir, dir), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return &recorder{
+		ID:        id,
+		RoomID:    room.ID,
+		StartedBy: startedBy,
+		StartedAt: time.Now(),
+		dir:       dir,
+		tracks:    make(map[*PublishedTrack]*recordedTrack),
+		ended:     make(map[*PublishedTrack]bool),
+	}, nil
+}
+
+// writeRTP records a forwarded packet. A simulcast track is recorded
+// from the first layer that reaches the recorder. The file is written on
+// its own goroutine, a file that falls behind loses packets instead of
+// holding up the forwarding.
+func (r *recorder) writeRTP(track *PublishedTrack, layer string, packet *rtp.Packet) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped || r.ended[track] {
+		return
+	}
+
+	recorded, ok := r.tracks[track]
+	if !ok {
+		recorded = r.openTrack(track, layer)
+		r.tracks[track] = recorded
+	}
+	if recorded.writer == nil || recorded.layer != layer {
+		return
+	}
+
+	select {
+	case recorded.packets <- packet.Clone():
+	default:
+		recorded.dropped++
+	}
+}
+
+// writeFile drains the packets of a track into its file until the channel is closed
+func (r *recorder) writeFile(recorded *recordedTrack) {
+	defer r.writers.Done()
+
+	failed := false
+	for packet := range recorded.packets {
+		if failed {
+			continue
+		}
+		if err := recorded.writer.WriteRTP(packet); err != nil {
+			logger.Warnf("Recording %s of %s failed: %v", recorded.TrackID, r.ID, err)
+			failed = true
+		}
+	}
+	if err := recorded.writer.Close(); err != nil {
+		logger.Warnf("Closing %s of %s failed: %v", recorded.File, r.ID, err)
+	}
+}
+
+// openTrack must be called with r.mu held. A track that can't be recorded
+// gets no writer so it isn't retried on every packet.
+func (r *recorder) openTrack(track *PublishedTrack, layer string) *recordedTrack {
+	now := time.Now()
+	recorded := &recordedTrack{
+		Username:  track.Owner,
+		TrackID:   track.ID,
+		Kind:      track.Kind.String(),
+		Source:    string(track.Source),
+		Codec:     track.codec.MimeType,
+		OffsetMs:  now.Sub(r.StartedAt).Milliseconds(),
+		layer:     layer,
+		startedAt: now,
+	}
+
+	// The same track id comes back when a participant rejoins
+	name := fmt.Sprintf("%s-%d", track.ID, len(r.tracks)+len(r.finished))
+	writer, fileName, err := newMediaWriter(filepath.Join(files.UploadDir, r.dir, name), track.codec)
+	if err != nil {
+		logger.Errorf("Can't record %s in %s: %v", track.ID, r.ID, err)
+		return recorded
+	}
+	recorded.writer = writer
+	recorded.File = filepath.Base(fileName)
+	recorded.packets = make(chan *rtp.Packet, recordingQueueSize)
+	r.writers.Add(1)
+	go r.writeFile(recorded)
+
+	// Video files must start with a keyframe
+	track.RequestKeyframe()
+	logger.Infof("Recording %s to %s", track.ID, fileName)
+	return recorded
+}
+
+// trackEnded closes the file of an unpublished track
+func (r *recorder) trackEnded(track *PublishedTrack) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ended[track] = true
+	recorded, ok := r.tracks[track]
+	if !ok {
+		return
+	}
+	delete(r.tracks, track)
+	r.finishTrack(recorded)
+}
+
+// finishTrack must be called with r.mu held. The file is closed by its
+// goroutine once the queued packets are written.
+func (r *recorder) finishTrack(recorded *recordedTrack) {
+	recorded.DurationMs = time.Since(recorded.startedAt).Milliseconds()
+	if recorded.writer == nil {
+		return
+	}
+	close(recorded.packets)
+	if recorded.dropped > 0 {
+		logger.Warnf("Recording %s of %s dropped %d packets", recorded.TrackID, r.ID, recorded.dropped)
+	}
+	r.finished = append(r.finished, recorded)
+}
+
+// stop closes every file, writes the manifest and registers the recording
+func (r *recorder) stop() (database.Recording, error) {
+	r.mu.Lock()
+	r.stopped = true
+	for track, recorded := range r.tracks {
+		delete(r.tracks, track)
+		r.finishTrack(recorded)
+	}
+	manifest := recordingManifest{
+		ID:        r.ID,
+		RoomID:    r.RoomID,
+		StartedBy: r.StartedBy,
+		StartedAt: r.StartedAt,
+		EndedAt:   time.Now(),
+		Tracks:    r.finished,
+	}
+	r.mu.Unlock()
+	r.writers.Wait()
+
+	manifestName := filepath.Join(r.dir, manifestFileName)
+	recording := database.Recording{
+		ID:        r.ID,
+		RoomID:    r.RoomID,
+		StartedBy: r.StartedBy,
+		StartedAt: manifest.StartedAt,
+		EndedAt:   manifest.EndedAt,
+		URL:       files.URL(manifestName),
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return recording, err
+	}
+	if err := os.WriteFile(filepath.Join(files.UploadDir, manifestName), data, 0o644); err != nil {
+		return recording, err
+	}
+
+	return recording, database.InsertRecording(database.GetDB(), recording)
+}
+
+// newMediaWriter creates the file for a codec, the extension is added to basePath
+func newMediaWriter(basePath string, codec webrtc.RTPCodecCapability) (mediaWriter, string, error) {
+	switch {
+	case strings.EqualFold(codec.MimeType, webrtc.MimeTypeOpus):
+		fileName := basePath + ".ogg"
+		writer, err := oggwriter.New(fileName, codec.ClockRate, codec.Channels)
+		if err != nil {
+			return nil, "", err
+		}
+		return writer, fileName, nil
+	case strings.EqualFold(codec.MimeType, webrtc.MimeTypeVP8),
+		strings.EqualFold(codec.MimeType, webrtc.MimeTypeVP9):
+		fileName := basePath + ".ivf"
+		writer, err := ivfwriter.New(fileName, ivfwriter.WithCodec(codec.MimeType))
+		if err != nil {
+			return nil, "", err
+		}
+		return writer, fileName, nil
+	case strings.EqualFold(codec.MimeType, webrtc.MimeTypeH264):
+		fileName := basePath + ".h264"
+		writer, err := h264writer.New(fileName)
+		if err != nil {
+			return nil, "", err
+		}
+		return writer, fileName, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported codec %s", codec.MimeType)
+	}
+}
+
+func (r *recorder) startedMessage() *common.OutgoingMessage {
+	return common.NewMessage(common.MessageTypeRecordingStarted, common.RecordingStartedPayload{
+		RecordingID: r.ID,
+		RoomID:      r.RoomID,
+		StartedBy:   r.StartedBy,
+	})
+}
+
+// stopRecording finishes the room recording if there is one
+func (r *Room) stopRecording(stoppedBy string) bool {
+	rec := r.recording.Swap(nil)
+	if rec == nil {
+		return false
+	}
+
+	recording, err := rec.stop()
+	if err != nil {
+		logger.Errorf("Не удалось сохранить запись %s: %v", rec.ID, err)
+	} else {
+		logger.Infof("Запись %s комнаты '%s' сохранена", rec.ID, r.ID)
+	}
+
+	r.broadcast(common.NewMessage(common.MessageTypeRecordingStopped, common.RecordingStoppedPayload{
+		RecordingID: rec.ID,
+		RoomID:      r.ID,
+		StoppedBy:   stoppedBy,
+		URL:         recording.URL,
+	}))
+	return true
+}
+
+// HandleStartRecording starts recording the call of a moderator
+func HandleStartRecording(context common.ClientContext) {
+	client, ok := callModerator(context, "Недостаточно прав для записи звонка.")
+	if !ok {
+		return
+	}
+	room := client.Room
+
+	rec, err := newRecorder(room, client.Username)
+	if err != nil {
+		logger.Errorf("Не удалось начать запись: %v", err)
+		sendError(context, "Не удалось начать запись.")
+		return
+	}
+	if !room.recording.CompareAndSwap(nil, rec) {
+		os.RemoveAll(filepath.Join(files.UploadDir, rec.dir))
+		sendError(context, "Запись звонка уже идёт.")
+		return
+	}
+
+	logger.Infof("'%s' начал запись %s комнаты '%s'", client.Username, rec.ID, room.ID)
+	room.broadcast(rec.startedMessage())
+}
+
+// HandleStopRecording stops the recording of the moderator call
+func HandleStopRecording(context common.ClientContext) {
+	client, ok := callModerator(context, "Недостаточно прав для записи звонка.")
+	if !ok {
+		return
+	}
+	if !client.Room.stopRecording(client.Username) {
+		sendError(context, "Запись звонка не идёт.")
+	}
+}
+
+// callModerator returns the call of a moderator, denied is sent to others
+func callModerator(context common.ClientContext, denied string) (*Client, bool) {
+	if !moderatorRoles[context.GetRole()] {
+		sendError(context, denied)
+		return nil, false
+	}
+
+	m := GetManager()
+	m.mu.RLock()
+	client, ok := m.Clients[context.GetUsername()]
+	m.mu.RUnlock()
+	if !ok {
+		sendError(context, "Сначала нужно войти в звонок.")
+		return nil, false
+	}
+	return client, true
+}
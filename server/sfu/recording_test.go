@@ -0,0 +1,23 @@
+package sfu
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+)
+
+func TestRecorderIgnoresEndedTrack(t *testing.T) {
+	rec := &recorder{
+		ID:     "ended",
+		tracks: make(map[*PublishedTrack]*recordedTrack),
+		ended:  make(map[*PublishedTrack]bool),
+	}
+	track := &PublishedTrack{ID: "alice-camera"}
+
+	rec.trackEnded(track)
+	rec.writeRTP(track, "", &rtp.Packet{})
+
+	if len(rec.tracks) != 0 || len(rec.finished) != 0 {
+		t.Errorf("a packet in flight reopened the ended track: %d open, %d finished", len(rec.tracks), len(rec.finished))
+	}
+}
@@ -0,0 +1,248 @@
+package sfu
+
+import (
+	"server/common"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
+
+const (
+	// reconnectGrace is how long a call survives without its websocket
+	reconnectGrace = 30 * time.Second
+	// iceRestartTimeout closes a failed peer connection that didn't recover
+	iceRestartTimeout = 15 * time.Second
+)
+
+// signaling is the websocket side of a call participant. The websocket can
+// reconnect while the peer connection keeps running: a new connection is
+// attached in place of the old one, messages sent while detached are dropped.
+type signaling struct {
+	mu         sync.RWMutex
+	context    common.ClientContext
+	attached   bool
+	generation uint64
+}
+
+func newSignaling(context common.ClientContext) *signaling {
+	return &signaling{context: context, attached: true}
+}
+
+func (s *signaling) current() common.ClientContext {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.context
+}
+
+func (s *signaling) GetUsername() string {
+	return s.current().GetUsername()
+}
+
+func (s *signaling) GetRole() string {
+	return s.current().GetRole()
+}
+
+func (s *signaling) Supports(capability string) bool {
+	return s.current().Supports(capability)
+}
+
+func (s *signaling) Send(message *common.OutgoingMessage) {
+	s.mu.RLock()
+	context, attached := s.context, s.attached
+	s.mu.RUnlock()
+
+	if !attached {
+		logger.Tracef("Dropped %s for detached %s", message.Type, context.GetUsername())
+		return
+	}
+	context.Send(message)
+}
+
+func (s *signaling) attach(context common.ClientContext) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.context = context
+	s.attached = true
+	s.generation++
+}
+
+// detach forgets the connection unless a newer one is already attached
+func (s *signaling) detach(context common.ClientContext) (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.attached || s.context != context {
+		return 0, false
+	}
+	s.attached = false
+	s.generation++
+	return s.generation, true
+}
+
+func (s *signaling) isAttached() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.attached
+}
+
+// attachedSince reports whether the connection attached at generation is still there
+func (s *signaling) attachedSince(generation uint64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.attached && s.generation == generation
+}
+
+func (s *signaling) currentGeneration() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.generation
+}
+
+// detachedSince reports whether nothing was attached after detach returned generation
+func (s *signaling) detachedSince(generation uint64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.attached && s.generation == generation
+}
+
+// DetachClient is called when the websocket of a participant goes away.
+// Media keeps flowing and the participant is removed only if no new
+// connection reattaches within the grace window.
+func (m *Manager) DetachClient(context common.ClientContext) {
+	m.mu.RLock()
+	client, ok := m.Clients[context.GetUsername()]
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	generation, ok := client.signaling.detach(context)
+	if !ok {
+		return
+	}
+	logger.Infof("Сигнальное соединение %s потеряно, ждём переподключения %s", client.Username, reconnectGrace)
+
+	time.AfterFunc(reconnectGrace, func() {
+		if !client.signaling.detachedSince(generation) {
+			return
+		}
+		m.mu.RLock()
+		current := m.Clients[client.Username]
+		m.mu.RUnlock()
+		if current != client {
+			return
+		}
+		logger.Infof("%s не переподключился, удаляем из звонка", client.Username)
+		m.RemoveClient(client.Username)
+	})
+}
+
+// ResumeCall reattaches a reconnected websocket to the call the user is
+// still in. A call whose websocket is still connected isn't taken over,
+// that takes an explicit join_call. Returns false if there is nothing to resume.
+func ResumeCall(context common.ClientContext) bool {
+	m := GetManager()
+	m.mu.RLock()
+	client, ok := m.Clients[context.GetUsername()]
+	m.mu.RUnlock()
+	if !ok || client.static || client.signaling.isAttached() {
+		return false
+	}
+
+	m.resume(client, context)
+	return true
+}
+
+func (m *Manager) resume(client *Client, context common.ClientContext) {
+	client.signaling.attach(context)
+	logger.Infof("%s вернулся в звонок '%s'", client.Username, client.Room.ID)
+
+	client.negotiationMu.Lock()
+	// Our offer may have been lost with the old connection
+	if client.PeerConnection.SignalingState() == webrtc.SignalingStateHaveLocalOffer {
+		rollback := webrtc.SessionDescription{Type: webrtc.SDPTypeRollback}
+		if err := client.PeerConnection.SetLocalDescription(rollback); err != nil {
+			logger.Warnf("Rollback for %s failed: %v", client.Username, err)
+		}
+		client.negotiationPending = true
+	}
+	// A new websocket often means a new network
+	iceRestart := false
+	switch client.PeerConnection.ICEConnectionState() {
+	case webrtc.ICEConnectionStateConnected, webrtc.ICEConnectionStateCompleted:
+	default:
+		client.iceRestart = true
+		client.negotiationPending = true
+		iceRestart = true
+	}
+	renegotiate := client.negotiationPending
+	client.negotiationMu.Unlock()
+
+	client.Context.Send(common.NewMessage(common.MessageTypeJoinCallSuccess, common.JoinCallSuccessPayload{
+		RoomID:     client.Room.ID,
+		ICEServers: ICEServersFor(client.Username),
+		AudioMix:   client.audioMix,
+		Resumed:    true,
+	}))
+	m.sendCallState(client)
+
+	if renegotiate {
+		client.negotiate()
+	}
+	if iceRestart {
+		client.closeIfNotRecovered()
+	}
+}
+
+// sendCallState repeats what a resumed client may have missed while detached
+func (m *Manager) sendCallState(client *Client) {
+	for _, track := range client.Room.tracks() {
+		if !client.canReceive(track) {
+			continue
+		}
+		client.Context.Send(common.NewMessage(common.MessageTypeTrackPublished, common.TrackPublishedPayload{
+			Username: track.Owner,
+			TrackID:  track.ID,
+			StreamID: track.StreamID,
+			Kind:     track.Kind.String(),
+			Source:   string(track.Source),
+		}))
+	}
+
+	if client.audioMix {
+		client.Context.Send(mixTrackPublished())
+	}
+
+	if rec := client.Room.recording.Load(); rec != nil {
+		client.Context.Send(rec.startedMessage())
+	}
+}
+
+// restartICE renegotiates with new ICE credentials, closing the
+// connection if it doesn't recover
+func (c *Client) restartICE() {
+	c.negotiationMu.Lock()
+	c.iceRestart = true
+	c.negotiationMu.Unlock()
+
+	logger.Infof("ICE restart for %s", c.Username)
+	c.negotiate()
+	c.closeIfNotRecovered()
+}
+
+// closeIfNotRecovered closes the peer connection if it is still failed
+// after the restart. A client that loses its websocket meanwhile is left
+// to the reconnect grace, the new connection restarts ICE again.
+func (c *Client) closeIfNotRecovered() {
+	generation := c.signaling.currentGeneration()
+	time.AfterFunc(iceRestartTimeout, func() {
+		if !c.signaling.attachedSince(generation) {
+			return
+		}
+		if c.PeerConnection.ConnectionState() == webrtc.PeerConnectionStateFailed {
+			logger.Warnf("ICE restart for %s didn't help, closing", c.Username)
+			c.PeerConnection.Close()
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package sfu
+
+import "testing"
+
+func TestResumeOnlyDetachedCall(t *testing.T) {
+	first := newTestPeer(t, "resume-user")
+	client := first.join("resume", JoinOptions{ViewOnly: true})
+
+	second := newTestPeer(t, "resume-user")
+	if ResumeCall(second) {
+		t.Fatal("a connected call was taken over by a new connection")
+	}
+	if client.signaling.current() != first {
+		t.Fatal("the signaling moved to the new connection")
+	}
+
+	GetManager().DetachClient(first)
+	if !ResumeCall(second) {
+		t.Fatal("the detached call wasn't resumed")
+	}
+	if client.signaling.current() != second || !client.signaling.isAttached() {
+		t.Fatal("the signaling wasn't attached to the new connection")
+	}
+}
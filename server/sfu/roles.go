@@ -0,0 +1,44 @@
+package sfu
+
+import "sync"
+
+var (
+	screenShareRoles = map[string]bool{
+		"admin":     true,
+		"moderator": true,
+		"peasant":   false,
+	}
+	screenShareMu sync.RWMutex
+
+	// moderatorRoles can mute and remove other call participants
+	moderatorRoles = map[string]bool{
+		"admin":     true,
+		"moderator": true,
+	}
+)
+
+// SetScreenSharePolicy allows or blocks screen sharing for a role
+func SetScreenSharePolicy(role string, allowed bool) {
+	screenShareMu.Lock()
+	defer screenShareMu.Unlock()
+	screenShareRoles[role] = allowed
+}
+
+func canShareScreen(role string) bool {
+	screenShareMu.RLock()
+	defer screenShareMu.RUnlock()
+	return screenShareRoles[role]
+}
+
+// IsModerator says whether the role may moderate calls
+func IsModerator(role string) bool {
+	return moderatorRoles[role]
+}
+
+// canModerate says whether a participant with role may control the target
+func canModerate(role string, targetRole string) bool {
+	if !moderatorRoles[role] {
+		return false
+	}
+	return role == "admin" || targetRole != "admin"
+}
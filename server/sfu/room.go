@@ -0,0 +1,115 @@
+package sfu
+
+import (
+	"server/common"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRoomID is used when join_call doesn't name a room
+const DefaultRoomID = "default"
+
+// Room is one call: its participants and the tracks they publish.
+// Tracks are only forwarded between members of the same room.
+type Room struct {
+	ID        string
+	StartedAt time.Time
+	Clients   map[string]*Client
+	Tracks    map[string]*PublishedTrack
+	// participants is everyone who joined the call, kept after they leave
+	participants map[string]*callParticipant
+	// hands is the raised-hand queue, first raised first
+	hands    []string
+	speakers *speakerDetector
+	stats    *callStats
+	// recording is set while the call is being recorded
+	recording atomic.Pointer[recorder]
+	// audioMixer is started when the first participant asks for mixed audio
+	audioMixer atomic.Pointer[audioMixer]
+	// bot plays media into the call while set
+	bot atomic.Pointer[mediaBot]
+	mu  sync.RWMutex
+}
+
+func newRoom(id string) *Room {
+	room := &Room{
+		ID:        id,
+		StartedAt: time.Now(),
+		Clients:   make(map[string]*Client),
+		Tracks:    make(map[string]*PublishedTrack),
+
+		participants: make(map[string]*callParticipant),
+	}
+	room.speakers = newSpeakerDetector(room)
+	room.stats = newCallStats(room)
+	return room
+}
+
+// getOrCreateRoom must be called with m.mu held
+func (m *Manager) getOrCreateRoom(id string) *Room {
+	room, ok := m.Rooms[id]
+	if !ok {
+		room = newRoom(id)
+		m.Rooms[id] = room
+		go room.speakers.run()
+		go room.stats.run()
+		logger.Infof("Room '%s' created", id)
+	}
+	return room
+}
+
+// members returns a snapshot of the room participants
+func (r *Room) members() []*Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clients := make([]*Client, 0, len(r.Clients))
+	for _, client := range r.Clients {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// tracks returns a snapshot of the tracks published in the room
+func (r *Room) tracks() []*PublishedTrack {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tracks := make([]*PublishedTrack, 0, len(r.Tracks))
+	for _, track := range r.Tracks {
+		tracks = append(tracks, track)
+	}
+	return tracks
+}
+
+func (r *Room) track(trackID string) (*PublishedTrack, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	track, ok := r.Tracks[trackID]
+	return track, ok
+}
+
+// broadcast sends the message to every participant of the room
+func (r *Room) broadcast(message *common.OutgoingMessage) {
+	for _, client := range r.members() {
+		client.Context.Send(message)
+	}
+}
+
+// close stops the room background work, called once the room is empty.
+// Must be called without the room lock.
+func (r *Room) close() {
+	r.speakers.stop()
+	r.stats.stop()
+	r.stats.persist(time.Now())
+	r.stopRecording("")
+	if mixer := r.audioMixer.Load(); mixer != nil {
+		mixer.stop()
+	}
+	if bot := r.bot.Load(); bot != nil {
+		bot.stop()
+	}
+	r.finishCall(time.Now())
+}
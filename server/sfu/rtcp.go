@@ -0,0 +1,137 @@
+package sfu
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v4"
+)
+
+const (
+	// keyframeRequestInterval throttles PLIs relayed to one publisher track
+	keyframeRequestInterval = 500 * time.Millisecond
+	// subscriberKeyframePeriod and subscriberKeyframeWindow control the
+	// keyframe requests sent while a new subscriber starts decoding
+	subscriberKeyframePeriod = time.Second
+	subscriberKeyframeWindow = 5 * time.Second
+)
+
+// RequestKeyframe asks the publisher for a keyframe, at most once per interval
+func (t *PublishedTrack) RequestKeyframe() {
+	if t.Kind != webrtc.RTPCodecTypeVideo || t.publisher == nil {
+		return
+	}
+
+	t.mu.Lock()
+	if time.Since(t.lastKeyframeRequest) < keyframeRequestInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.lastKeyframeRequest = time.Now()
+	packets := make([]rtcp.Packet, 0, len(t.ssrcs))
+	for _, ssrc := range t.ssrcs {
+		packets = append(packets, &rtcp.PictureLossIndication{MediaSSRC: uint32(ssrc)})
+	}
+	t.mu.Unlock()
+
+	if len(packets) == 0 {
+		return
+	}
+
+	err := t.publisher.WriteRTCP(packets)
+	if err != nil {
+		logger.Warnf("Failed to send PLI for %s: %v", t.ID, err)
+		return
+	}
+	logger.Tracef("PLI sent to %s for %s", t.Owner, t.ID)
+}
+
+// requestKeyframesForSubscriber keeps asking for keyframes for a short while,
+// the first request can be lost before the subscriber is connected
+func (t *PublishedTrack) requestKeyframesForSubscriber() {
+	if t.Kind != webrtc.RTPCodecTypeVideo {
+		return
+	}
+
+	t.RequestKeyframe()
+
+	ticker := time.NewTicker(subscriberKeyframePeriod)
+	defer ticker.Stop()
+	deadline := time.After(subscriberKeyframeWindow)
+
+	for {
+		select {
+		case <-ticker.C:
+			t.RequestKeyframe()
+		case <-deadline:
+			return
+		}
+	}
+}
+
+// readSenderRTCP reads feedback from a subscriber and relays keyframe
+// requests upstream. It also lets interceptors process NACKs and reports.
+func readSenderRTCP(subscriber string, sender *webrtc.RTPSender, track *PublishedTrack) {
+	for {
+		packets, _, err := sender.ReadRTCP()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.Debugf("RTCP reader for %s (%s) stopped: %v", subscriber, track.ID, err)
+			}
+			return
+		}
+
+		for _, packet := range packets {
+			switch packet.(type) {
+			case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+				track.RequestKeyframe()
+			}
+		}
+	}
+}
+
+// attachTrack adds the track to the subscriber and starts its RTCP reader.
+// Simulcast tracks get a dedicated local track for the subscriber.
+func (m *Manager) attachTrack(client *Client, track *PublishedTrack) (*webrtc.RTPSender, error) {
+	track.mu.RLock()
+	existing, subscribed := track.subscriptions[client.Username]
+	track.mu.RUnlock()
+	if subscribed {
+		return existing.sender, nil
+	}
+
+	var local webrtc.TrackLocal = track.Local
+	var layered *webrtc.TrackLocalStaticRTP
+	switch {
+	case track.sample != nil:
+		local = track.sample
+	case track.Simulcast:
+		var err error
+		layered, err = webrtc.NewTrackLocalStaticRTP(track.codec, track.ID, track.StreamID)
+		if err != nil {
+			return nil, err
+		}
+		local = layered
+	}
+
+	sender, err := client.PeerConnection.AddTrack(local)
+	if err != nil {
+		return nil, err
+	}
+
+	track.mu.Lock()
+	track.subscriptions[client.Username] = &subscription{client: client, sender: sender, local: local}
+	track.mu.Unlock()
+
+	if track.Simulcast {
+		track.addDownTrack(client.Username, layered)
+	}
+
+	go readSenderRTCP(client.Username, sender, track)
+	go track.requestKeyframesForSubscriber()
+	client.bandwidth.tracksChanged()
+
+	return sender, nil
+}
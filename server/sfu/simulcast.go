@@ -0,0 +1,274 @@
+package sfu
+
+import (
+	"server/common"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+)
+
+// Simulcast layers in ascending quality, named by their rid
+const (
+	LayerQuarter = "q"
+	LayerHalf    = "h"
+	LayerFull    = "f"
+)
+
+var layerOrder = []string{LayerQuarter, LayerHalf, LayerFull}
+
+// layerBitrates is the bandwidth a subscriber needs to receive a layer
+var layerBitrates = map[string]uint64{
+	LayerQuarter: 150_000,
+	LayerHalf:    500_000,
+	LayerFull:    1_500_000,
+}
+
+func layerIndex(layer string) int {
+	for i, l := range layerOrder {
+		if l == layer {
+			return i
+		}
+	}
+	return -1
+}
+
+// downTrack is the per-subscriber track of a simulcast publication.
+// Every subscriber gets its own SSRC (set by the local track binding)
+// and continuous sequence numbers and timestamps across layer switches.
+type downTrack struct {
+	subscriber string
+	local      *webrtc.TrackLocalStaticRTP
+
+	mu             sync.Mutex
+	currentLayer   string
+	targetLayer    string
+	preferredLayer string
+	estimate       uint64
+
+	started   bool
+	seqOffset uint16
+	tsOffset  uint32
+	lastSeq   uint16
+	lastTS    uint32
+	lastWrite time.Time
+}
+
+func newDownTrack(subscriber string, local *webrtc.TrackLocalStaticRTP) *downTrack {
+	return &downTrack{
+		subscriber:     subscriber,
+		local:          local,
+		preferredLayer: LayerFull,
+	}
+}
+
+// writeRTP forwards a packet of the given layer. Switching to the target
+// layer only happens on a keyframe so the decoder never sees a broken frame.
+func (d *downTrack) writeRTP(layer string, packet *rtp.Packet, keyframe bool) error {
+	d.mu.Lock()
+	if layer != d.currentLayer {
+		if layer != d.targetLayer || !keyframe {
+			d.mu.Unlock()
+			return nil
+		}
+		d.switchTo(layer, packet)
+	}
+
+	out := *packet
+	out.SequenceNumber = packet.SequenceNumber + d.seqOffset
+	out.Timestamp = packet.Timestamp + d.tsOffset
+
+	d.lastSeq = out.SequenceNumber
+	d.lastTS = out.Timestamp
+	d.lastWrite = time.Now()
+	d.mu.Unlock()
+
+	return d.local.WriteRTP(&out)
+}
+
+// switchTo rebases sequence numbers and timestamps of the new layer onto
+// what the subscriber already got. Must be called with d.mu held.
+func (d *downTrack) switchTo(layer string, packet *rtp.Packet) {
+	if d.started {
+		// 90 kHz video clock
+		elapsed := uint32(time.Since(d.lastWrite).Milliseconds() * 90)
+		if elapsed == 0 {
+			elapsed = 1
+		}
+		d.seqOffset = d.lastSeq + 1 - packet.SequenceNumber
+		d.tsOffset = d.lastTS + elapsed - packet.Timestamp
+	}
+
+	logger.Debugf("Subscriber %s switched from layer %q to %q", d.subscriber, d.currentLayer, layer)
+	d.currentLayer = layer
+	d.started = true
+}
+
+// selectLayer picks the best published layer allowed by the subscriber
+// preference and its bandwidth estimate
+func (t *PublishedTrack) selectLayer(d *downTrack) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	d.mu.Lock()
+	preferred := layerIndex(d.preferredLayer)
+	estimate := d.estimate
+	d.mu.Unlock()
+
+	if preferred < 0 {
+		preferred = len(layerOrder) - 1
+	}
+
+	selected := ""
+	for i := 0; i <= preferred; i++ {
+		layer := layerOrder[i]
+		if _, ok := t.ssrcs[layer]; !ok {
+			continue
+		}
+		if estimate > 0 && layerBitrates[layer] > estimate && selected != "" {
+			break
+		}
+		selected = layer
+	}
+
+	// Nothing at or below the preference is published, take the lowest one
+	if selected == "" {
+		for _, layer := range layerOrder {
+			if _, ok := t.ssrcs[layer]; ok {
+				return layer
+			}
+		}
+	}
+	return selected
+}
+
+// updateLayer re-evaluates the target layer of a subscriber
+func (t *PublishedTrack) updateLayer(d *downTrack) {
+	target := t.selectLayer(d)
+
+	d.mu.Lock()
+	changed := target != d.targetLayer
+	d.targetLayer = target
+	d.mu.Unlock()
+
+	if changed {
+		logger.Debugf("Target layer of %s for %s is now %q", t.ID, d.subscriber, target)
+		t.RequestKeyframe()
+	}
+}
+
+// onBandwidthEstimate is called with the share of the subscriber estimate
+// the bandwidth controller gives the track
+func (t *PublishedTrack) onBandwidthEstimate(subscriber string, bitrate uint64) {
+	if !t.Simulcast {
+		return
+	}
+
+	t.mu.RLock()
+	d, ok := t.downTracks[subscriber]
+	t.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	d.mu.Lock()
+	d.estimate = bitrate
+	d.mu.Unlock()
+
+	t.updateLayer(d)
+}
+
+// addLayer registers another rid of a simulcast publication
+func (t *PublishedTrack) addLayer(remoteTrack *webrtc.TrackRemote) {
+	t.mu.Lock()
+	t.ssrcs[remoteTrack.RID()] = remoteTrack.SSRC()
+	downTracks := make([]*downTrack, 0, len(t.downTracks))
+	for _, d := range t.downTracks {
+		downTracks = append(downTracks, d)
+	}
+	t.mu.Unlock()
+
+	logger.Infof("Simulcast layer %q of %s is published", remoteTrack.RID(), t.ID)
+
+	for _, d := range downTracks {
+		t.updateLayer(d)
+	}
+}
+
+func (t *PublishedTrack) addDownTrack(subscriber string, local *webrtc.TrackLocalStaticRTP) *downTrack {
+	d := newDownTrack(subscriber, local)
+
+	t.mu.Lock()
+	t.downTracks[subscriber] = d
+	t.mu.Unlock()
+
+	t.updateLayer(d)
+	return d
+}
+
+// writeRTP fans out a packet read from one layer of the publisher
+func (t *PublishedTrack) writeRTP(layer string, packet *rtp.Packet) error {
+	if !t.Simulcast {
+		return t.Local.WriteRTP(packet)
+	}
+
+	keyframe := isKeyframe(t.codec.MimeType, packet.Payload)
+
+	t.mu.RLock()
+	downTracks := make([]*downTrack, 0, len(t.downTracks))
+	for _, d := range t.downTracks {
+		downTracks = append(downTracks, d)
+	}
+	t.mu.RUnlock()
+
+	for _, d := range downTracks {
+		if err := d.writeRTP(layer, packet, keyframe); err != nil {
+			logger.Warnf("Write to %s for %s failed: %v", d.subscriber, t.ID, err)
+		}
+	}
+	return nil
+}
+
+// HandleSetPreferredLayer stores the highest layer the client wants for a track
+func HandleSetPreferredLayer(context common.ClientContext, payload common.Payload) {
+	var layerPayload common.PreferredLayerPayload
+	if err := payload.Decode(&layerPayload); err != nil {
+		sendError(context, "Некорректные данные для команды set_preferred_layer.")
+		return
+	}
+
+	if layerIndex(layerPayload.Layer) < 0 {
+		sendError(context, "Неизвестный слой, допустимы q, h, f.")
+		return
+	}
+
+	m := GetManager()
+	m.mu.RLock()
+	client, ok := m.Clients[context.GetUsername()]
+	m.mu.RUnlock()
+	if !ok {
+		sendError(context, "Сначала нужно войти в звонок.")
+		return
+	}
+
+	track, ok := client.Room.track(layerPayload.TrackID)
+	if !ok || !track.Simulcast {
+		sendError(context, "Трек не найден или не использует simulcast.")
+		return
+	}
+
+	track.mu.RLock()
+	d, ok := track.downTracks[context.GetUsername()]
+	track.mu.RUnlock()
+	if !ok {
+		sendError(context, "Вы не подписаны на этот трек.")
+		return
+	}
+
+	d.mu.Lock()
+	d.preferredLayer = layerPayload.Layer
+	d.mu.Unlock()
+
+	track.updateLayer(d)
+}
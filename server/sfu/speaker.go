@@ -0,0 +1,161 @@
+package sfu
+
+import (
+	"server/common"
+	"sync"
+	"time"
+)
+
+const (
+	// speakerTick is how often levels are decayed and the active speaker re-evaluated
+	speakerTick = 200 * time.Millisecond
+	// audioLevelsInterval is how often audio_levels is broadcast
+	audioLevelsInterval = time.Second
+	// speakerSilenceTimeout decays participants that stopped sending (DTX)
+	speakerSilenceTimeout = 300 * time.Millisecond
+	// speakerSwitchHold keeps the active speaker for a while to avoid flicker
+	speakerSwitchHold = 800 * time.Millisecond
+	// speakerClearHold is how long nobody speaks before the active speaker is cleared
+	speakerClearHold = 2 * time.Second
+
+	// speakerSmoothing is the weight of a new sample in the moving average
+	speakerSmoothing = 0.1
+	// speakerThreshold is the smoothed level (0..1) considered speaking
+	speakerThreshold = 0.25
+
+	// maxAudioLevel is silence in the ssrc-audio-level extension (-127 dBov)
+	maxAudioLevel = 127
+)
+
+type speakerLevel struct {
+	smoothed   float64
+	lastUpdate time.Time
+}
+
+// speakerDetector tracks smoothed audio levels of one room and
+// announces who is talking
+type speakerDetector struct {
+	room *Room
+
+	mu            sync.Mutex
+	levels        map[string]*speakerLevel
+	active        string
+	activeSince   time.Time
+	lastBroadcast time.Time
+	// quietSince is when nobody was above the threshold any more, zero while someone speaks
+	quietSince time.Time
+
+	done chan struct{}
+	once sync.Once
+}
+
+func newSpeakerDetector(room *Room) *speakerDetector {
+	return &speakerDetector{
+		room:   room,
+		levels: make(map[string]*speakerLevel),
+		done:   make(chan struct{}),
+	}
+}
+
+// observe feeds the audio level of one RTP packet, 0 is loudest, 127 is silence
+func (d *speakerDetector) observe(username string, level uint8) {
+	if level > maxAudioLevel {
+		level = maxAudioLevel
+	}
+	loudness := float64(maxAudioLevel-level) / maxAudioLevel
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	speaker, ok := d.levels[username]
+	if !ok {
+		speaker = &speakerLevel{}
+		d.levels[username] = speaker
+	}
+	speaker.smoothed += speakerSmoothing * (loudness - speaker.smoothed)
+	speaker.lastUpdate = time.Now()
+}
+
+func (d *speakerDetector) forget(username string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.levels, username)
+}
+
+func (d *speakerDetector) run() {
+	ticker := time.NewTicker(speakerTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.tick()
+		case <-d.done:
+			return
+		}
+	}
+}
+
+func (d *speakerDetector) stop() {
+	d.once.Do(func() {
+		close(d.done)
+	})
+}
+
+func (d *speakerDetector) tick() {
+	now := time.Now()
+
+	d.mu.Lock()
+	loudest := ""
+	loudestLevel := speakerThreshold
+	for username, speaker := range d.levels {
+		if now.Sub(speaker.lastUpdate) > speakerSilenceTimeout {
+			speaker.smoothed *= 1 - speakerSmoothing
+		}
+		if speaker.smoothed > loudestLevel {
+			loudest = username
+			loudestLevel = speaker.smoothed
+		}
+	}
+
+	changed := false
+	switch {
+	case loudest != "":
+		d.quietSince = time.Time{}
+		if loudest != d.active && (d.active == "" || now.Sub(d.activeSince) >= speakerSwitchHold) {
+			d.active = loudest
+			d.activeSince = now
+			changed = true
+		}
+	case d.quietSince.IsZero():
+		d.quietSince = now
+	case d.active != "" && now.Sub(d.quietSince) >= speakerClearHold:
+		// Nobody talks any more, stop highlighting the last speaker
+		d.active = ""
+		d.activeSince = now
+		changed = true
+	}
+	active := d.active
+
+	var levels map[string]float64
+	if len(d.levels) > 0 && now.Sub(d.lastBroadcast) >= audioLevelsInterval {
+		d.lastBroadcast = now
+		levels = make(map[string]float64, len(d.levels))
+		for username, speaker := range d.levels {
+			levels[username] = speaker.smoothed
+		}
+	}
+	d.mu.Unlock()
+
+	if changed {
+		logger.Debugf("Active speaker in room '%s' is %q", d.room.ID, active)
+		d.room.broadcast(common.NewMessage(common.MessageTypeActiveSpeakerChanged, common.ActiveSpeakerPayload{
+			Username: active,
+		}))
+	}
+	if levels != nil {
+		d.room.broadcast(common.NewMessage(common.MessageTypeAudioLevels, common.AudioLevelsPayload{
+			Levels: levels,
+		}))
+	}
+}
@@ -0,0 +1,302 @@
+package sfu
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/common"
+	"server/database"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// statsInterval is how often GetStats is collected for every participant
+const statsInterval = 5 * time.Second
+
+// statsCounters are the cumulative byte counters of the previous sample
+type statsCounters struct {
+	bytesReceived uint64
+	bytesSent     uint64
+	at            time.Time
+}
+
+// qualityTotals add up the samples of a participant for the call summary
+type qualityTotals struct {
+	samples         int
+	rttMs           float64
+	jitterMs        float64
+	packetLoss      float64
+	inboundBitrate  float64
+	outboundBitrate float64
+}
+
+// callStats samples the peer connections of a room on an interval
+type callStats struct {
+	room *Room
+
+	mu       sync.Mutex
+	current  map[string]common.ParticipantStats
+	previous map[string]statsCounters
+	// totals outlive the participant so the summary covers everyone
+	totals map[string]*qualityTotals
+
+	done chan struct{}
+	once sync.Once
+}
+
+func newCallStats(room *Room) *callStats {
+	return &callStats{
+		room:     room,
+		current:  make(map[string]common.ParticipantStats),
+		previous: make(map[string]statsCounters),
+		totals:   make(map[string]*qualityTotals),
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *callStats) run() {
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.collect()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *callStats) stop() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *callStats) collect() {
+	for _, client := range s.room.members() {
+		report := client.PeerConnection.GetStats()
+
+		s.mu.Lock()
+		stats, counters := participantStats(client.Username, report, s.previous[client.Username])
+		if client.bandwidth != nil {
+			stats.EstimatedBitrate = client.bandwidth.currentEstimate()
+			stats.PausedTracks = client.pausedTracks()
+		}
+		s.current[client.Username] = stats
+		s.previous[client.Username] = counters
+
+		totals, ok := s.totals[client.Username]
+		if !ok {
+			totals = &qualityTotals{}
+			s.totals[client.Username] = totals
+		}
+		totals.samples++
+		totals.rttMs += stats.RTTMs
+		totals.jitterMs += stats.JitterMs
+		totals.packetLoss += stats.PacketLoss
+		totals.inboundBitrate += float64(stats.InboundBitrate)
+		totals.outboundBitrate += float64(stats.OutboundBitrate)
+		s.mu.Unlock()
+	}
+}
+
+// participantStats reduces a stats report to the numbers we show.
+// Jitter and loss describe the media the server receives from the
+// participant, bitrates are computed against the previous sample.
+func participantStats(username string, report webrtc.StatsReport, previous statsCounters) (common.ParticipantStats, statsCounters) {
+	stats := common.ParticipantStats{Username: username}
+	counters := statsCounters{at: time.Now()}
+
+	var jitterSum float64
+	var jitterCount int
+	var packetsReceived uint64
+	var pairRTT, remoteRTT float64
+
+	for _, entry := range report {
+		switch stat := entry.(type) {
+		case webrtc.InboundRTPStreamStats:
+			counters.bytesReceived += stat.BytesReceived
+			packetsReceived += uint64(stat.PacketsReceived)
+			stats.PacketsLost += int64(stat.PacketsLost)
+			jitterSum += stat.Jitter
+			jitterCount++
+		case webrtc.OutboundRTPStreamStats:
+			counters.bytesSent += stat.BytesSent
+		case webrtc.RemoteInboundRTPStreamStats:
+			if stat.RoundTripTime > remoteRTT {
+				remoteRTT = stat.RoundTripTime
+			}
+		case webrtc.ICECandidatePairStats:
+			if stat.Nominated && stat.CurrentRoundTripTime > 0 {
+				pairRTT = stat.CurrentRoundTripTime
+			}
+		}
+	}
+
+	rtt := pairRTT
+	if rtt == 0 {
+		rtt = remoteRTT
+	}
+	stats.RTTMs = rtt * 1000
+	if jitterCount > 0 {
+		stats.JitterMs = jitterSum / float64(jitterCount) * 1000
+	}
+	// PacketsLost goes negative with duplicates
+	if lost := stats.PacketsLost; lost > 0 {
+		stats.PacketLoss = float64(lost) / float64(packetsReceived+uint64(lost))
+	}
+
+	if !previous.at.IsZero() {
+		seconds := counters.at.Sub(previous.at).Seconds()
+		if seconds > 0 && counters.bytesReceived >= previous.bytesReceived && counters.bytesSent >= previous.bytesSent {
+			stats.InboundBitrate = uint64(float64(counters.bytesReceived-previous.bytesReceived) * 8 / seconds)
+			stats.OutboundBitrate = uint64(float64(counters.bytesSent-previous.bytesSent) * 8 / seconds)
+		}
+	}
+
+	return stats, counters
+}
+
+// forget drops the live stats of a participant who left, totals are kept
+func (s *callStats) forget(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.current, username)
+	delete(s.previous, username)
+}
+
+// snapshot returns the latest sample of every participant
+func (s *callStats) snapshot() []common.ParticipantStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	participants := make([]common.ParticipantStats, 0, len(s.current))
+	for _, stats := range s.current {
+		participants = append(participants, stats)
+	}
+	sort.Slice(participants, func(i, j int) bool {
+		return participants[i].Username < participants[j].Username
+	})
+	return participants
+}
+
+// persist saves the per-participant quality summary of the finished call
+func (s *callStats) persist(endedAt time.Time) {
+	s.mu.Lock()
+	summaries := make([]database.CallQuality, 0, len(s.totals))
+	for username, totals := range s.totals {
+		if totals.samples == 0 {
+			continue
+		}
+		samples := float64(totals.samples)
+		summaries = append(summaries, database.CallQuality{
+			RoomID:             s.room.ID,
+			Username:           username,
+			StartedAt:          s.room.StartedAt,
+			EndedAt:            endedAt,
+			Samples:            totals.samples,
+			AvgRTTMs:           totals.rttMs / samples,
+			AvgJitterMs:        totals.jitterMs / samples,
+			AvgPacketLoss:      totals.packetLoss / samples,
+			AvgInboundBitrate:  totals.inboundBitrate / samples,
+			AvgOutboundBitrate: totals.outboundBitrate / samples,
+		})
+	}
+	s.mu.Unlock()
+
+	if len(summaries) == 0 {
+		return
+	}
+	if err := database.InsertCallQuality(database.GetDB(), summaries); err != nil {
+		logger.Errorf("Не удалось сохранить качество звонка в комнате '%s': %v", s.room.ID, err)
+	}
+}
+
+// CallStats returns the latest stats of every active call
+func (m *Manager) CallStats() []common.CallStatsPayload {
+	m.mu.RLock()
+	rooms := make([]*Room, 0, len(m.Rooms))
+	for _, room := range m.Rooms {
+		rooms = append(rooms, room)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].ID < rooms[j].ID
+	})
+
+	calls := make([]common.CallStatsPayload, 0, len(rooms))
+	for _, room := range rooms {
+		calls = append(calls, common.CallStatsPayload{
+			RoomID:       room.ID,
+			Participants: room.stats.snapshot(),
+		})
+	}
+	return calls
+}
+
+// HandleGetCallStats sends the stats of a call to a moderator.
+// Without room_id the moderator's own call is used.
+func HandleGetCallStats(context common.ClientContext, payload common.Payload) {
+	if !moderatorRoles[context.GetRole()] {
+		sendError(context, "Недостаточно прав для просмотра статистики звонка.")
+		return
+	}
+
+	var request common.GetCallStatsPayload
+	if !payload.Empty() {
+		if err := payload.Decode(&request); err != nil {
+			sendError(context, "Некорректные данные для команды get_call_stats.")
+			return
+		}
+	}
+
+	m := GetManager()
+	m.mu.RLock()
+	if request.RoomID == "" {
+		if client, ok := m.Clients[context.GetUsername()]; ok {
+			request.RoomID = client.Room.ID
+		}
+	}
+	room, ok := m.Rooms[request.RoomID]
+	m.mu.RUnlock()
+	if !ok {
+		sendError(context, "Звонок не найден.")
+		return
+	}
+
+	context.Send(common.NewMessage(common.MessageTypeCallStats, common.CallStatsPayload{
+		RoomID:       room.ID,
+		Participants: room.stats.snapshot(),
+	}))
+}
+
+// HandleCallStatsHTTP serves the stats of every call to admins,
+// authenticated with HTTP basic auth
+func HandleCallStatsHTTP(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="patterns"`)
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	role, err := database.Authenticate(database.GetDB(), username, password)
+	if err != nil {
+		http.Error(w, "Authentication failed", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(GetManager().CallStats()); err != nil {
+		logger.Errorf("Encode call stats failed: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package sfu
+
+import (
+	"server/common"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// subscription is one subscriber receiving a published track
+type subscription struct {
+	client *Client
+	sender *webrtc.RTPSender
+	// local is what the sender sends, swapped out while paused
+	local  webrtc.TrackLocal
+	paused bool
+}
+
+// unpublishTrack removes the track from every subscriber and from the room.
+// RemoveTrack marks the subscriber connections for renegotiation.
+func (m *Manager) unpublishTrack(room *Room, track *PublishedTrack) {
+	room.mu.Lock()
+	current, ok := room.Tracks[track.ID]
+	if ok && current == track {
+		delete(room.Tracks, track.ID)
+	}
+	owner := room.Clients[track.Owner]
+	room.mu.Unlock()
+
+	if !ok || current != track {
+		return
+	}
+
+	track.mu.Lock()
+	subscriptions := track.subscriptions
+	track.subscriptions = make(map[string]*subscription)
+	track.downTracks = make(map[string]*downTrack)
+	track.mu.Unlock()
+
+	if rec := room.recording.Load(); rec != nil {
+		rec.trackEnded(track)
+	}
+	if mixer := room.audioMixer.Load(); mixer != nil && track.Kind == webrtc.RTPCodecTypeAudio {
+		mixer.removeInput(track.Owner)
+	}
+
+	logger.Infof("Unpublishing %s from %d subscribers in room '%s'", track.ID, len(subscriptions), room.ID)
+
+	for _, sub := range subscriptions {
+		if err := sub.client.PeerConnection.RemoveTrack(sub.sender); err != nil {
+			logger.Warnf("Failed to remove %s from %s: %v", track.ID, sub.client.Username, err)
+		}
+		sub.client.bandwidth.tracksChanged()
+	}
+
+	if owner != nil {
+		owner.removeTrackID(track.ID)
+	}
+
+	payload := common.TrackPublishedPayload{
+		Username: track.Owner,
+		TrackID:  track.ID,
+		StreamID: track.StreamID,
+		Kind:     track.Kind.String(),
+		Source:   string(track.Source),
+	}
+	message := common.NewMessage(common.MessageTypeTrackUnpublished, payload)
+	for _, client := range room.members() {
+		if client.canReceive(track) {
+			client.Context.Send(message)
+		}
+	}
+}
+
+// detachSubscriber forgets the senders of a leaving subscriber.
+// Its peer connection is closed right after, so nothing to renegotiate.
+func (m *Manager) detachSubscriber(room *Room, client *Client) {
+	for _, track := range room.tracks() {
+		track.mu.Lock()
+		delete(track.subscriptions, client.Username)
+		delete(track.downTracks, client.Username)
+		track.mu.Unlock()
+	}
+}
+
+// SenderCount returns how many subscribers currently receive the track
+func (t *PublishedTrack) SenderCount() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.subscriptions)
+}
+
+func (c *Client) removeTrackID(trackID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, id := range c.TrackIDs {
+		if id == trackID {
+			c.TrackIDs = append(c.TrackIDs[:i], c.TrackIDs[i+1:]...)
+			return
+		}
+	}
+}
+
+// removeLayer forgets a finished layer and returns how many are left
+func (t *PublishedTrack) removeLayer(layer string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.ssrcs, layer)
+	return len(t.ssrcs)
+}
@@ -0,0 +1,327 @@
+package sfu
+
+import (
+	"encoding/json"
+	"server/common"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+const testTimeout = 10 * time.Second
+
+// testPeer is the browser side of a participant. It answers every offer of
+// the server, all work on its peer connection runs on one goroutine.
+type testPeer struct {
+	t        *testing.T
+	username string
+	pc       *webrtc.PeerConnection
+	actions  chan func()
+	done     chan struct{}
+	// offers counts the server offers answered
+	offers atomic.Int32
+}
+
+func newTestPeer(t *testing.T, username string) *testPeer {
+	t.Helper()
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatalf("new peer connection: %v", err)
+	}
+	p := &testPeer{
+		t:        t,
+		username: username,
+		pc:       pc,
+		actions:  make(chan func(), 64),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	t.Cleanup(func() {
+		close(p.done)
+		pc.Close()
+	})
+	return p
+}
+
+func (p *testPeer) GetUsername() string             { return p.username }
+func (p *testPeer) GetRole() string                 { return "peasant" }
+func (p *testPeer) Supports(capability string) bool { return false }
+func (p *testPeer) Send(message *common.OutgoingMessage) {
+	payload, ok := message.Payload.(common.SdpPayload)
+	if !ok {
+		return
+	}
+	switch message.Type {
+	case common.MessageTypeSdpOffer:
+		p.actions <- func() { p.answer(payload) }
+	case common.MessageTypeSdpAnswer:
+		p.actions <- func() {
+			answer := webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: payload.SDP}
+			if err := p.pc.SetRemoteDescription(answer); err != nil {
+				p.t.Errorf("%s: set answer: %v", p.username, err)
+			}
+		}
+	}
+}
+
+func (p *testPeer) run() {
+	for {
+		select {
+		case action := <-p.actions:
+			action()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *testPeer) answer(offer common.SdpPayload) {
+	// An offer crossing ours lost the glare, the server sends a new one
+	if p.pc.SignalingState() != webrtc.SignalingStateStable {
+		return
+	}
+	description := webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: offer.SDP}
+	if err := p.pc.SetRemoteDescription(description); err != nil {
+		p.t.Errorf("%s: set offer: %v", p.username, err)
+		return
+	}
+	answer, err := p.pc.CreateAnswer(nil)
+	if err != nil {
+		p.t.Errorf("%s: create answer: %v", p.username, err)
+		return
+	}
+	HandleSDPAnswer(p.username, p.localDescription(answer, offer.NegotiationID))
+	p.offers.Add(1)
+}
+
+// offer sends an offer of the browser, like after adding or removing a track
+func (p *testPeer) offer() {
+	p.actions <- func() {
+		offer, err := p.pc.CreateOffer(nil)
+		if err != nil {
+			p.t.Errorf("%s: create offer: %v", p.username, err)
+			return
+		}
+		HandleSDPOffer(p, p.localDescription(offer, 0))
+	}
+}
+
+// localDescription sets the description and returns it with all candidates
+func (p *testPeer) localDescription(description webrtc.SessionDescription, negotiationID uint64) common.Payload {
+	gathered := webrtc.GatheringCompletePromise(p.pc)
+	if err := p.pc.SetLocalDescription(description); err != nil {
+		p.t.Errorf("%s: set local description: %v", p.username, err)
+	}
+	<-gathered
+
+	data, _ := json.Marshal(common.SdpPayload{
+		Type:          p.pc.LocalDescription().Type.String(),
+		SDP:           p.pc.LocalDescription().SDP,
+		NegotiationID: negotiationID,
+	})
+	return common.JSONPayload(data)
+}
+
+// publish adds a microphone track and keeps sending Opus frames on it
+func (p *testPeer) publish() *webrtc.RTPSender {
+	p.t.Helper()
+
+	track, err := webrtc.NewTrackLocalStaticSample(
+		webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+		"audio", p.username,
+	)
+	if err != nil {
+		p.t.Fatalf("new track: %v", err)
+	}
+	sender, err := p.pc.AddTrack(track)
+	if err != nil {
+		p.t.Fatalf("add track: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		// A silent 20 ms Opus frame
+		frame := []byte{0xf8, 0xff, 0xfe}
+		for {
+			select {
+			case <-ticker.C:
+				track.WriteSample(media.Sample{Data: frame, Duration: 20 * time.Millisecond})
+			case <-p.done:
+				return
+			}
+		}
+	}()
+	return sender
+}
+
+func (p *testPeer) join(roomID string, options JoinOptions) *Client {
+	p.t.Helper()
+
+	client, err := GetManager().AddClient(p, roomID, options)
+	if err != nil {
+		p.t.Fatalf("join %s: %v", p.username, err)
+	}
+	p.t.Cleanup(func() {
+		GetManager().RemoveClient(p.username)
+	})
+	return client
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(testTimeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// activeSenders counts the senders still carrying a track. RemoveTrack keeps
+// the sender on its transceiver, only without a track.
+func activeSenders(pc *webrtc.PeerConnection) int {
+	count := 0
+	for _, sender := range pc.GetSenders() {
+		if sender.Track() != nil {
+			count++
+		}
+	}
+	return count
+}
+
+func stable(client *Client) bool {
+	return client.PeerConnection.SignalingState() == webrtc.SignalingStateStable
+}
+
+// testCall is a publisher with one audio track and two subscribers
+type testCall struct {
+	room       *Room
+	publisher  *testPeer
+	sender     *webrtc.RTPSender
+	publisherC *Client
+	peers      []*testPeer
+	clients    []*Client
+	track      *PublishedTrack
+}
+
+func setupCall(t *testing.T, roomID string) *testCall {
+	t.Helper()
+
+	call := &testCall{}
+	for _, name := range []string{"first", "second"} {
+		peer := newTestPeer(t, roomID+"-"+name)
+		call.peers = append(call.peers, peer)
+		call.clients = append(call.clients, peer.join(roomID, JoinOptions{ViewOnly: true}))
+	}
+
+	call.publisher = newTestPeer(t, roomID+"-publisher")
+	call.sender = call.publisher.publish()
+	call.publisherC = call.publisher.join(roomID, JoinOptions{})
+	call.room = call.publisherC.Room
+
+	waitFor(t, "the published track", func() bool {
+		return len(call.room.tracks()) == 1
+	})
+	call.track = call.room.tracks()[0]
+
+	waitFor(t, "both subscribers", func() bool {
+		return call.track.SenderCount() == 2
+	})
+	for i, client := range call.clients {
+		peer := call.peers[i]
+		waitFor(t, "the subscriber offer to be answered", func() bool {
+			return activeSenders(client.PeerConnection) == 1 && peer.offers.Load() > 0 && stable(client)
+		})
+	}
+	return call
+}
+
+// assertUnpublished checks the senders are gone and the subscribers get a new offer
+func (call *testCall) assertUnpublished(t *testing.T, offersBefore []int32) {
+	t.Helper()
+
+	waitFor(t, "the track to be unpublished", func() bool {
+		_, ok := call.room.track(call.track.ID)
+		return !ok
+	})
+	if count := call.track.SenderCount(); count != 0 {
+		t.Errorf("SenderCount() = %d after unpublish, want 0", count)
+	}
+	for i, client := range call.clients {
+		if count := activeSenders(client.PeerConnection); count != 0 {
+			t.Errorf("%s still sends %d tracks", client.Username, count)
+		}
+		peer := call.peers[i]
+		waitFor(t, "renegotiation of "+peer.username, func() bool {
+			return peer.offers.Load() > offersBefore[i]
+		})
+	}
+}
+
+func (call *testCall) offerCounts() []int32 {
+	counts := make([]int32, len(call.peers))
+	for i, peer := range call.peers {
+		counts[i] = peer.offers.Load()
+	}
+	return counts
+}
+
+func TestTeardownPublisherLeaves(t *testing.T) {
+	call := setupCall(t, "teardown-leave")
+	offers := call.offerCounts()
+
+	GetManager().RemoveClient(call.publisher.username)
+
+	call.assertUnpublished(t, offers)
+}
+
+func TestTeardownTrackEnds(t *testing.T) {
+	call := setupCall(t, "teardown-track-end")
+	offers := call.offerCounts()
+
+	call.publisher.actions <- func() {
+		if err := call.publisher.pc.RemoveTrack(call.sender); err != nil {
+			t.Errorf("remove track: %v", err)
+		}
+	}
+	call.publisher.offer()
+
+	call.assertUnpublished(t, offers)
+	if _, ok := GetManager().ClientRoom(call.publisher.username); !ok {
+		t.Error("publisher left the call, only its track should have ended")
+	}
+}
+
+func TestTeardownPublisherFails(t *testing.T) {
+	call := setupCall(t, "teardown-failure")
+	offers := call.offerCounts()
+
+	// What the ICE restart timer does when the connection doesn't recover
+	call.publisherC.PeerConnection.Close()
+
+	call.assertUnpublished(t, offers)
+	waitFor(t, "the publisher to be removed", func() bool {
+		_, ok := GetManager().ClientRoom(call.publisher.username)
+		return !ok
+	})
+}
+
+func TestTeardownSubscriberLeaves(t *testing.T) {
+	call := setupCall(t, "teardown-subscriber")
+
+	GetManager().RemoveClient(call.peers[0].username)
+
+	if count := call.track.SenderCount(); count != 1 {
+		t.Errorf("SenderCount() = %d after a subscriber left, want 1", count)
+	}
+	if count := activeSenders(call.clients[1].PeerConnection); count != 1 {
+		t.Errorf("remaining subscriber sends %d tracks, want 1", count)
+	}
+}
@@ -0,0 +1,48 @@
+package sfu
+
+import (
+	"net"
+	cl "server/color-logger"
+	"server/config"
+
+	"github.com/pion/turn/v4"
+)
+
+// StartTURNServer runs the embedded TURN server if it is enabled
+func StartTURNServer(cfg config.TURNConfig) (*turn.Server, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	udpListener, err := net.ListenPacket("udp4", cfg.ListenAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := turn.NewServer(turn.ServerConfig{
+		Realm:         cfg.Realm,
+		LoggerFactory: cl.Factory,
+		AuthHandler: func(username string, realm string, srcAddr net.Addr) ([]byte, bool) {
+			if turnCredentialsExpired(username) {
+				logger.Debugf("Expired TURN credentials %s from %s", username, srcAddr)
+				return nil, false
+			}
+			return turn.GenerateAuthKey(username, realm, turnPassword(cfg.Secret, username)), true
+		},
+		PacketConnConfigs: []turn.PacketConnConfig{
+			{
+				PacketConn: udpListener,
+				RelayAddressGenerator: &turn.RelayAddressGeneratorStatic{
+					RelayAddress: net.ParseIP(cfg.PublicIP),
+					Address:      "0.0.0.0",
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("TURN server listening on %s, relay address %s", cfg.ListenAddress, cfg.PublicIP)
+	return server, nil
+}
@@ -3,25 +3,106 @@ package sfu
 import (
 	"server/common"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/pion/interceptor/pkg/cc"
 	"github.com/pion/webrtc/v4"
 )
 
 type Manager struct {
-	Clients     map[string]*Client
-	TrackLocals map[string]*webrtc.TrackLocalStaticRTP
-	mu          sync.RWMutex
+	// Clients indexes every participant by username, whatever the room
+	Clients map[string]*Client
+	Rooms   map[string]*Room
+	api     *webrtc.API
+	mu      sync.RWMutex
+
+	// estimators receives the bandwidth estimator of the peer connection
+	// being created, peerMu pairs them up
+	estimators chan cc.BandwidthEstimator
+	peerMu     sync.Mutex
 }
 
 type Client struct {
 	Username       string
 	PeerConnection *webrtc.PeerConnection
-	Context        common.ClientContext
-	TrackIDs       []string
-	mu             sync.RWMutex
+	// Context is the signaling of the participant, it survives websocket reconnects
+	Context   common.ClientContext
+	signaling *signaling
+	Room      *Room
+	TrackIDs  []string
+	// sources maps our recvonly transceivers to what the client publishes on them
+	sources map[*webrtc.RTPTransceiver]TrackSource
+	// declaredSources are sources announced by the client per remote track id
+	declaredSources map[string]TrackSource
+	// pendingCandidates wait for the remote description
+	pendingCandidates []webrtc.ICECandidateInit
+	// muted are the kinds a moderator stopped forwarding
+	muted map[webrtc.RTPCodecType]bool
+	// audioMix clients get the room mixer output instead of audio tracks
+	audioMix bool
+	// static clients never renegotiate, see JoinOptions
+	static bool
+	// events is the data channel for reactions, hands, chat and pointer
+	events *webrtc.DataChannel
+	// bandwidth pauses video sent to the client under congestion
+	bandwidth *bandwidthController
+
+	// negotiationMu serializes offer/answer exchanges with the client
+	negotiationMu      sync.Mutex
+	negotiationID      uint64
+	negotiationPending bool
+	// iceRestart makes the next offer restart ICE
+	iceRestart bool
+	mu         sync.RWMutex
+}
+
+// TrackSource tells receivers what a track carries
+type TrackSource string
+
+const (
+	TrackSourceMicrophone TrackSource = "microphone"
+	TrackSourceCamera     TrackSource = "camera"
+	TrackSourceScreen     TrackSource = "screen"
+)
+
+// PublishedTrack is a track received from one client and forwarded to the others.
+// A single stream is shared through Local, a simulcast one gets a
+// downTrack per subscriber so each can receive its own layer.
+type PublishedTrack struct {
+	ID        string
+	StreamID  string
+	Owner     string
+	Kind      webrtc.RTPCodecType
+	Source    TrackSource
+	Simulcast bool
+	Local     *webrtc.TrackLocalStaticRTP
+	// sample replaces Local for tracks produced by the server, like the media bot
+	sample *webrtc.TrackLocalStaticSample
+
+	codec         webrtc.RTPCodecCapability
+	downTracks    map[string]*downTrack
+	subscriptions map[string]*subscription
+
+	// publisher and ssrcs (by rid) are where keyframe requests are relayed to
+	publisher           *webrtc.PeerConnection
+	ssrcs               map[string]webrtc.SSRC
+	lastKeyframeRequest time.Time
+	// remoteID is the publisher track id, shared by the simulcast layers
+	remoteID string
+
+	// audioLevelExtID is the negotiated id of ssrc-audio-level, 0 if absent
+	audioLevelExtID uint8
+	// muted drops the packets instead of forwarding them
+	muted atomic.Bool
+
+	mu sync.RWMutex
 }
 
 type Event struct {
 	InitiatorUsername string
+	RoomID            string
 	Type              string
+	// Payload is sent as is, events without it get username and room_id
+	Payload interface{}
 }
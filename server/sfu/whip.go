@@ -0,0 +1,277 @@
+package sfu
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"server/common"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/pion/webrtc/v4"
+)
+
+const (
+	sdpContentType = "application/sdp"
+	// maxOfferSize limits the SDP body of WHIP/WHEP requests
+	maxOfferSize = 64 << 10
+	// streamRole is the role of WHIP/WHEP participants
+	streamRole = "peasant"
+	// StreamUsernamePrefix namespaces WHIP/WHEP participants so a token
+	// name never takes the place of a chat user in a call
+	StreamUsernamePrefix = "stream:"
+)
+
+// streamContext stands in for the websocket of WHIP/WHEP participants.
+// There is no signaling channel after the answer, messages are dropped.
+type streamContext struct {
+	username string
+}
+
+func (c *streamContext) GetUsername() string { return c.username }
+
+func (c *streamContext) GetRole() string { return streamRole }
+
+func (c *streamContext) Supports(capability string) bool {
+	return capability == common.CapabilityVideo
+}
+
+func (c *streamContext) Send(message *common.OutgoingMessage) {
+	logger.Tracef("Dropped %s for stream participant %s", message.Type, c.username)
+}
+
+// streamSession is who started a WHIP/WHEP resource
+type streamSession struct {
+	username string
+	// name is the stream token name
+	name   string
+	roomID string
+	prefix string
+}
+
+var (
+	// streamSessions maps WHIP/WHEP resource ids to their sessions
+	streamSessions   = make(map[string]streamSession)
+	streamSessionsMu sync.Mutex
+)
+
+// ReservedUsername tells whether a chat user may not take the name
+func ReservedUsername(username string) bool {
+	return strings.HasPrefix(username, StreamUsernamePrefix) || username == MediaBotUsername
+}
+
+// HandleWHIP ingests a stream into a call room: POST /whip/{room}.
+// DELETE on the returned resource ends the stream.
+func HandleWHIP(w http.ResponseWriter, r *http.Request) {
+	roomID, resourceID := parseStreamPath(r.URL.Path, "/whip/")
+	handleStreamRequest(w, r, "/whip/", roomID, resourceID, publishStream)
+}
+
+// HandleWHEP plays the tracks of a call room: POST /whep/{room}.
+// DELETE on the returned resource stops playback.
+func HandleWHEP(w http.ResponseWriter, r *http.Request) {
+	roomID, resourceID := parseStreamPath(r.URL.Path, "/whep/")
+	handleStreamRequest(w, r, "/whep/", roomID, resourceID, playStream)
+}
+
+// startStream creates the participant for an offer, the status is used on error
+type startStream func(m *Manager, name string, roomID string, resourceID string) (*Client, int, error)
+
+func handleStreamRequest(w http.ResponseWriter, r *http.Request, prefix string, roomID string, resourceID string, start startStream) {
+	if roomID == "" {
+		http.Error(w, "Room is required", http.StatusNotFound)
+		return
+	}
+
+	name, ok := streamTokenName(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && resourceID == "":
+	case r.Method == http.MethodDelete && resourceID != "":
+		endStream(w, streamSession{name: name, roomID: roomID, prefix: prefix}, resourceID)
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), sdpContentType) {
+		http.Error(w, "Content-Type must be application/sdp", http.StatusUnsupportedMediaType)
+		return
+	}
+	offer, err := io.ReadAll(io.LimitReader(r.Body, maxOfferSize))
+	if err != nil {
+		http.Error(w, "Failed to read offer", http.StatusBadRequest)
+		return
+	}
+
+	m := GetManager()
+	resourceID = uuid.New().String()
+	client, status, err := start(m, name, roomID, resourceID)
+	if err != nil {
+		logger.Warnf("Stream %s%s for %s failed: %v", prefix, roomID, name, err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	answer, err := client.answerOffer(string(offer))
+	if err != nil {
+		logger.Warnf("Stream offer from %s failed: %v", client.Username, err)
+		m.RemoveClient(client.Username)
+		http.Error(w, "Invalid offer", http.StatusBadRequest)
+		return
+	}
+
+	streamSessionsMu.Lock()
+	streamSessions[resourceID] = streamSession{
+		username: client.Username,
+		name:     name,
+		roomID:   roomID,
+		prefix:   prefix,
+	}
+	streamSessionsMu.Unlock()
+
+	logger.Infof("%s joined room '%s' over %s", client.Username, roomID, strings.Trim(prefix, "/"))
+
+	w.Header().Set("Content-Type", sdpContentType)
+	w.Header().Set("Location", prefix+roomID+"/"+resourceID)
+	w.WriteHeader(http.StatusCreated)
+	if _, err := io.WriteString(w, answer); err != nil {
+		logger.Warnf("Write answer to %s failed: %v", client.Username, err)
+	}
+}
+
+// publishStream joins the token owner as a publisher, like OBS or GStreamer
+func publishStream(m *Manager, name string, roomID string, _ string) (*Client, int, error) {
+	client, err := m.AddClient(&streamContext{username: StreamUsernamePrefix + name}, roomID, JoinOptions{Static: true})
+	if err != nil {
+		return nil, http.StatusConflict, err
+	}
+	return client, 0, nil
+}
+
+// playStream joins a viewer receiving the mixed audio and the video
+// published when it connects. Offer one recvonly video transceiver per
+// video you want, tracks published later aren't added.
+func playStream(m *Manager, name string, roomID string, resourceID string) (*Client, int, error) {
+	m.mu.RLock()
+	_, exists := m.Rooms[roomID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, http.StatusNotFound, errors.New("call not found")
+	}
+
+	username := StreamUsernamePrefix + name + "-" + resourceID[:8]
+	client, err := m.AddClient(&streamContext{username: username}, roomID, JoinOptions{
+		AudioMix: true,
+		ViewOnly: true,
+		Static:   true,
+	})
+	if err != nil {
+		return nil, http.StatusConflict, err
+	}
+
+	for _, track := range client.Room.tracks() {
+		if track.Kind != webrtc.RTPCodecTypeVideo {
+			continue
+		}
+		if _, err := m.attachTrack(client, track); err != nil {
+			logger.Errorf("Failed add %s to %s: %v", track.ID, username, err)
+		}
+	}
+	if err := client.Room.addMixListener(client); err != nil {
+		m.RemoveClient(username)
+		return nil, http.StatusInternalServerError, err
+	}
+	return client, 0, nil
+}
+
+// answerOffer runs the single offer/answer exchange of a WHIP/WHEP session.
+// Candidates are gathered before answering, there is no trickle.
+func (c *Client) answerOffer(sdp string) (string, error) {
+	c.negotiationMu.Lock()
+	defer c.negotiationMu.Unlock()
+
+	offer := webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: sdp}
+	if err := c.PeerConnection.SetRemoteDescription(offer); err != nil {
+		return "", err
+	}
+
+	answer, err := c.PeerConnection.CreateAnswer(nil)
+	if err != nil {
+		return "", err
+	}
+	if err := c.setLocalDescription(answer); err != nil {
+		return "", err
+	}
+	return c.PeerConnection.LocalDescription().SDP, nil
+}
+
+// endStream removes the participant of a resource. Only the token that
+// started it may end it, and only under the path it was created at.
+func endStream(w http.ResponseWriter, request streamSession, resourceID string) {
+	streamSessionsMu.Lock()
+	session, ok := streamSessions[resourceID]
+	if !ok || session.roomID != request.roomID || session.prefix != request.prefix {
+		streamSessionsMu.Unlock()
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if session.name != request.name {
+		streamSessionsMu.Unlock()
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	delete(streamSessions, resourceID)
+	streamSessionsMu.Unlock()
+
+	GetManager().RemoveClient(session.username)
+	w.WriteHeader(http.StatusOK)
+}
+
+// forgetStreamSession drops the resource of a participant that left
+func forgetStreamSession(username string) {
+	streamSessionsMu.Lock()
+	defer streamSessionsMu.Unlock()
+
+	for resourceID, session := range streamSessions {
+		if session.username == username {
+			delete(streamSessions, resourceID)
+		}
+	}
+}
+
+// streamTokenName checks the bearer token against the configured stream tokens
+func streamTokenName(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", false
+	}
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == "" {
+		return "", false
+	}
+
+	for known, name := range settings.StreamTokens {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// parseStreamPath splits /whip/{room}/{resource} into its parts
+func parseStreamPath(path string, prefix string) (string, string) {
+	parts := strings.SplitN(strings.TrimPrefix(path, prefix), "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
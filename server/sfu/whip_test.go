@@ -0,0 +1,68 @@
+package sfu
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEndStreamChecksOwner(t *testing.T) {
+	settings.StreamTokens = map[string]string{"obs-token": "obs", "other-token": "other"}
+	t.Cleanup(func() { settings.StreamTokens = nil })
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{name: "another token", path: "/whip/studio/resource", token: "other-token", status: http.StatusForbidden},
+		{name: "another room", path: "/whip/lobby/resource", token: "obs-token", status: http.StatusNotFound},
+		{name: "unknown resource", path: "/whip/studio/missing", token: "obs-token", status: http.StatusNotFound},
+		{name: "no token", path: "/whip/studio/resource", status: http.StatusUnauthorized},
+		{name: "owner", path: "/whip/studio/resource", token: "obs-token", status: http.StatusOK},
+		{name: "already ended", path: "/whip/studio/resource", token: "obs-token", status: http.StatusNotFound},
+	}
+
+	streamSessionsMu.Lock()
+	streamSessions["resource"] = streamSession{
+		username: StreamUsernamePrefix + "obs",
+		name:     "obs",
+		roomID:   "studio",
+		prefix:   "/whip/",
+	}
+	streamSessionsMu.Unlock()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodDelete, test.path, nil)
+			if test.token != "" {
+				request.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			recorder := httptest.NewRecorder()
+
+			HandleWHIP(recorder, request)
+
+			if recorder.Code != test.status {
+				t.Errorf("DELETE %s = %d, want %d", test.path, recorder.Code, test.status)
+			}
+		})
+	}
+}
+
+func TestReservedUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		reserved bool
+	}{
+		{username: "obs", reserved: false},
+		{username: StreamUsernamePrefix + "obs", reserved: true},
+		{username: MediaBotUsername, reserved: true},
+	}
+
+	for _, test := range tests {
+		if got := ReservedUsername(test.username); got != test.reserved {
+			t.Errorf("ReservedUsername(%q) = %v, want %v", test.username, got, test.reserved)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package ws
+
+import (
+	"server/common"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// MessageClass groups outgoing messages that share a backpressure policy
+type MessageClass string
+
+const (
+	MessageClassChat      MessageClass = "chat"
+	MessageClassPresence  MessageClass = "presence"
+	MessageClassSignaling MessageClass = "signaling"
+	MessageClassSystem    MessageClass = "system"
+	MessageClassDefault   MessageClass = "default"
+)
+
+// BackpressurePolicy says what to do when a client's send buffer is full
+type BackpressurePolicy int
+
+const (
+	// PolicyDropNewest drops the message that doesn't fit
+	PolicyDropNewest BackpressurePolicy = iota
+	// PolicyDropOldest evicts the oldest queued message that may be dropped
+	// to make room, the new message is dropped if there is none
+	PolicyDropOldest
+	// PolicyDisconnect never drops, the client is disconnected instead
+	PolicyDisconnect
+)
+
+const (
+	sendBufferSize = 256
+
+	// CloseSlowConsumer is sent in the close frame when a client can't keep up
+	CloseSlowConsumer = 4008
+
+	closeWriteWait = time.Second
+)
+
+func DefaultBackpressurePolicies() map[MessageClass]BackpressurePolicy {
+	return map[MessageClass]BackpressurePolicy{
+		MessageClassChat:      PolicyDisconnect,
+		MessageClassPresence:  PolicyDropOldest,
+		MessageClassSignaling: PolicyDisconnect,
+		MessageClassSystem:    PolicyDropNewest,
+		MessageClassDefault:   PolicyDropNewest,
+	}
+}
+
+// ClassifyMessage maps a message type to its backpressure class
+func ClassifyMessage(messageType string) MessageClass {
+	switch messageType {
+	case common.MessageTypeChat, common.MessageTypeGetMessagesResponse, common.MessageTypeCallHistory:
+		return MessageClassChat
+	case common.MessageTypeUserJoinWS, common.MessageTypeUserLeaveWS,
+		common.MessageTypeUserJoinSFU, common.MessageTypeUserLeaveSFU,
+		common.MessageTypeActiveClientsWSResponse, common.MessageTypeActiveClientsSFUResponse,
+		common.MessageTypePromoteUserResponse,
+		common.MessageTypeActiveSpeakerChanged, common.MessageTypeAudioLevels,
+		common.MessageTypeCallEnded:
+		return MessageClassPresence
+	case common.MessageTypeSdpOffer, common.MessageTypeSdpAnswer,
+		common.MessageTypeIceCandidate, common.MessageTypeJoinCallSuccess,
+		common.MessageTypeTrackPublished, common.MessageTypeTrackUnpublished,
+		common.MessageTypeTrackPaused,
+		common.MessageTypeParticipantMuted, common.MessageTypeParticipantRemoved,
+		common.MessageTypeCallRequestUnmute,
+		common.MessageTypeRecordingStarted, common.MessageTypeRecordingStopped,
+		common.MessageTypeMediaState,
+		common.MessageTypeIncomingCall, common.MessageTypeCallInviteStatus:
+		return MessageClassSignaling
+	case common.MessageTypeSystem, common.MessageTypeSystemError, common.MessageTypeResyncRequired:
+		return MessageClassSystem
+	default:
+		return MessageClassDefault
+	}
+}
+
+// SetBackpressurePolicy overrides the policy for one message class
+func (manager *Manager) SetBackpressurePolicy(class MessageClass, policy BackpressurePolicy) {
+	manager.policiesMu.Lock()
+	defer manager.policiesMu.Unlock()
+	manager.policies[class] = policy
+}
+
+func (manager *Manager) backpressurePolicy(class MessageClass) BackpressurePolicy {
+	manager.policiesMu.RLock()
+	defer manager.policiesMu.RUnlock()
+
+	if policy, ok := manager.policies[class]; ok {
+		return policy
+	}
+	return manager.policies[MessageClassDefault]
+}
+
+// DroppedMessages returns how many messages were dropped per class since start
+func (manager *Manager) DroppedMessages() map[MessageClass]uint64 {
+	manager.dropsMu.Lock()
+	defer manager.dropsMu.Unlock()
+
+	result := make(map[MessageClass]uint64, len(manager.drops))
+	for class, count := range manager.drops {
+		result[class] = count
+	}
+	return result
+}
+
+func (manager *Manager) countDrop(class MessageClass) {
+	manager.dropsMu.Lock()
+	manager.drops[class]++
+	manager.dropsMu.Unlock()
+}
+
+// droppable says whether messages of the class may be lost under backpressure
+func (manager *Manager) droppable(class MessageClass) bool {
+	return manager.backpressurePolicy(class) != PolicyDisconnect
+}
+
+// enqueue applies the backpressure policy of the message class. A drop is
+// counted under the class of the message that was actually lost.
+func (c *Client) enqueue(message []byte, class MessageClass) {
+	queue := c.queue
+	queue.mu.Lock()
+
+	if queue.closed {
+		queue.mu.Unlock()
+		logger.Debugf("Client %s already closed, message ignored", c.Username)
+		return
+	}
+	if queue.push(queuedMessage{data: message, class: class}) {
+		queue.mu.Unlock()
+		return
+	}
+
+	policy := c.manager.backpressurePolicy(class)
+	dropped := class
+	if policy == PolicyDropOldest {
+		if evicted, ok := queue.evictOldest(c.manager.droppable); ok {
+			queue.push(queuedMessage{data: message, class: class})
+			dropped = evicted
+		}
+	}
+	queue.mu.Unlock()
+
+	c.markDropped(dropped)
+	if policy == PolicyDisconnect {
+		logger.Warnf("Client %s can't keep up with %s messages, disconnecting", c.Username, class)
+		c.requestClose(CloseSlowConsumer, "slow consumer: "+string(class)+" backlog overflow")
+	}
+}
+
+func (c *Client) markDropped(class MessageClass) {
+	c.missed.Add(1)
+	c.manager.countDrop(class)
+	logger.Debugf("Dropped %s message for %s", class, c.Username)
+
+	select {
+	case c.resync <- struct{}{}:
+	default:
+	}
+}
+
+// requestClose lets writePump close the socket, enqueue runs on the hub
+// goroutine and can't wait for a slow client to accept a close frame
+func (c *Client) requestClose(code int, reason string) {
+	select {
+	case c.kick <- closeFrame{code: code, reason: reason}:
+	default:
+	}
+}
+
+// takeMissed returns the number of messages dropped since the last resync signal
+func (c *Client) takeMissed() uint64 {
+	return c.missed.Swap(0)
+}
+
+// resyncMessage tells the client it missed messages and should refetch state
+func (c *Client) resyncMessage(missed uint64) []byte {
+	message := common.NewMessage(common.MessageTypeResyncRequired, common.ResyncPayload{Missed: missed})
+	messageBytes, err := message.Encode(c.encoding)
+	if err != nil {
+		logger.Errorf("Error encoding resync message: %v", err)
+		return nil
+	}
+	return messageBytes
+}
+
+// disconnect sends a close frame with the reason and closes the socket.
+// readPump notices the closed connection and unregisters the client,
+// so the usual leave event is still emitted.
+func (c *Client) disconnect(code int, reason string) {
+	c.closeOnce.Do(func() {
+		err := c.conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(code, reason),
+			time.Now().Add(closeWriteWait),
+		)
+		if err != nil {
+			logger.Warnf("Write close frame for %s failed: %v", c.Username, err)
+		}
+		if err := c.conn.Close(); err != nil {
+			logger.Errorf("Close connection failed: %v", err)
+		}
+	})
+}
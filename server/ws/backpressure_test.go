@@ -0,0 +1,104 @@
+package ws
+
+import (
+	"strconv"
+	"testing"
+)
+
+func newTestClient() *Client {
+	manager := &Manager{
+		policies: DefaultBackpressurePolicies(),
+		drops:    make(map[MessageClass]uint64),
+	}
+	return &Client{
+		Username: "slow",
+		manager:  manager,
+		queue:    newSendQueue(),
+		kick:     make(chan closeFrame, 1),
+		resync:   make(chan struct{}, 1),
+	}
+}
+
+// drain pops everything queued for the client
+func drain(c *Client) []queuedMessage {
+	var messages []queuedMessage
+	for {
+		message, ok, _ := c.queue.pop()
+		if !ok {
+			return messages
+		}
+		messages = append(messages, message)
+	}
+}
+
+func TestPresenceNeverEvictsChat(t *testing.T) {
+	client := newTestClient()
+	for i := range sendBufferSize {
+		client.enqueue([]byte(strconv.Itoa(i)), MessageClassChat)
+	}
+
+	client.enqueue([]byte("presence"), MessageClassPresence)
+
+	messages := drain(client)
+	if len(messages) != sendBufferSize {
+		t.Fatalf("%d messages delivered, want %d", len(messages), sendBufferSize)
+	}
+	for i, message := range messages {
+		if message.class != MessageClassChat || string(message.data) != strconv.Itoa(i) {
+			t.Fatalf("message %d = %s %q, want chat %q", i, message.class, message.data, strconv.Itoa(i))
+		}
+	}
+
+	drops := client.manager.DroppedMessages()
+	if drops[MessageClassPresence] != 1 || drops[MessageClassChat] != 0 {
+		t.Errorf("drops = %v, want one presence drop", drops)
+	}
+	select {
+	case <-client.kick:
+		t.Error("a dropped presence message disconnected the client")
+	default:
+	}
+}
+
+func TestDropCountedUnderEvictedClass(t *testing.T) {
+	client := newTestClient()
+	client.manager.SetBackpressurePolicy(MessageClassDefault, PolicyDropOldest)
+
+	client.enqueue([]byte("chat"), MessageClassChat)
+	client.enqueue([]byte("presence"), MessageClassPresence)
+	for len(client.queue.items) < sendBufferSize {
+		client.enqueue([]byte("chat"), MessageClassChat)
+	}
+
+	client.enqueue([]byte("default"), MessageClassDefault)
+
+	drops := client.manager.DroppedMessages()
+	if drops[MessageClassPresence] != 1 || drops[MessageClassDefault] != 0 {
+		t.Errorf("drops = %v, want one presence drop", drops)
+	}
+	messages := drain(client)
+	if last := messages[len(messages)-1]; last.class != MessageClassDefault {
+		t.Errorf("last message is %s, want the new default message", last.class)
+	}
+	for _, message := range messages {
+		if message.class == MessageClassPresence {
+			t.Error("the presence message wasn't evicted")
+		}
+	}
+}
+
+func TestChatOverflowDisconnects(t *testing.T) {
+	client := newTestClient()
+	for range sendBufferSize + 1 {
+		client.enqueue([]byte("chat"), MessageClassChat)
+	}
+
+	select {
+	case frame := <-client.kick:
+		if frame.code != CloseSlowConsumer {
+			t.Errorf("close code = %d, want %d", frame.code, CloseSlowConsumer)
+		}
+	default:
+		t.Error("the client wasn't disconnected on a chat overflow")
+	}
+}
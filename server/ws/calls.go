@@ -0,0 +1,301 @@
+package ws
+
+import (
+	"server/common"
+	"server/database"
+	"server/sfu"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// callInviteTimeout is how long an incoming call rings before it expires
+const callInviteTimeout = 30 * time.Second
+
+// Statuses of an invite reported with call_invite_status
+const (
+	CallInviteRinging   = "ringing"
+	CallInviteAccepted  = "accepted"
+	CallInviteDeclined  = "declined"
+	CallInviteTimeout   = "timeout"
+	CallInviteCancelled = "cancelled"
+)
+
+// callInvite is one user being called, every invited user gets their own
+type callInvite struct {
+	ID     string
+	From   string
+	To     string
+	RoomID string
+	timer  *time.Timer
+}
+
+func (invite *callInvite) status(status string) *common.OutgoingMessage {
+	return common.NewMessage(common.MessageTypeCallInviteStatus, common.CallInviteStatusPayload{
+		InviteID: invite.ID,
+		Username: invite.To,
+		RoomID:   invite.RoomID,
+		Status:   status,
+	})
+}
+
+// HandleCallInvite rings a user, the members of the room, or everybody
+// online when neither is given
+func HandleCallInvite(client *Client, payload common.Payload) {
+	var invitePayload common.CallInvitePayload
+	if !payload.Empty() {
+		if err := payload.Decode(&invitePayload); err != nil {
+			sendSystemError(client, "Некорректные данные для команды call_invite.")
+			return
+		}
+	}
+
+	callRoom, inCall := sfu.GetManager().ClientRoom(client.Username)
+	if invitePayload.RoomID == "" {
+		if inCall {
+			invitePayload.RoomID = callRoom
+		} else {
+			invitePayload.RoomID = sfu.DefaultRoomID
+		}
+	}
+	// Only participants ring others into their call, moderators anywhere
+	if (!inCall || callRoom != invitePayload.RoomID) && !sfu.IsModerator(client.GetRole()) {
+		sendSystemError(client, "Приглашать в звонок могут только его участники.")
+		return
+	}
+
+	manager := GetManager()
+	var targets []string
+	switch {
+	case invitePayload.Room:
+		members, err := database.GetRoomMembers(database.GetDB(), invitePayload.RoomID)
+		if err != nil {
+			logger.Errorf("Не удалось получить участников комнаты '%s': %v", invitePayload.RoomID, err)
+			sendSystemError(client, "Не удалось получить участников комнаты.")
+			return
+		}
+		targets = manager.invitable(client, invitePayload.RoomID, members)
+	case invitePayload.Username != "":
+		if invitePayload.Username == client.Username {
+			sendSystemError(client, "Нельзя позвонить самому себе.")
+			return
+		}
+		if len(manager.clientsByUsername(invitePayload.Username)) == 0 {
+			sendSystemError(client, "Пользователь не в сети.")
+			return
+		}
+		targets = []string{invitePayload.Username}
+	default:
+		targets = manager.invitable(client, invitePayload.RoomID, manager.onlineUsernames())
+	}
+
+	if len(targets) == 0 {
+		sendSystemError(client, "Некого пригласить в звонок.")
+		return
+	}
+
+	for _, target := range targets {
+		invite := &callInvite{
+			ID:     uuid.New().String(),
+			From:   client.Username,
+			To:     target,
+			RoomID: invitePayload.RoomID,
+		}
+
+		manager.invitesMu.Lock()
+		manager.invites[invite.ID] = invite
+		invite.timer = time.AfterFunc(callInviteTimeout, func() {
+			manager.expireInvite(invite.ID)
+		})
+		manager.invitesMu.Unlock()
+
+		logger.Infof("'%s' звонит '%s' в комнату '%s'", invite.From, invite.To, invite.RoomID)
+
+		manager.sendToUser(target, common.NewMessage(common.MessageTypeIncomingCall, common.IncomingCallPayload{
+			InviteID:       invite.ID,
+			From:           invite.From,
+			RoomID:         invite.RoomID,
+			TimeoutSeconds: int(callInviteTimeout.Seconds()),
+		}))
+		client.Send(invite.status(CallInviteRinging))
+	}
+}
+
+// invitable keeps the users that are online and not in the call yet
+func (manager *Manager) invitable(client *Client, roomID string, usernames []string) []string {
+	var targets []string
+	for _, username := range usernames {
+		if username == client.Username || len(manager.clientsByUsername(username)) == 0 {
+			continue
+		}
+		if callRoom, ok := sfu.GetManager().ClientRoom(username); ok && callRoom == roomID {
+			continue
+		}
+		targets = append(targets, username)
+	}
+	return targets
+}
+
+// HandleCallAccept answers an incoming call and joins the call room
+func HandleCallAccept(client *Client, payload common.Payload) {
+	var reply common.CallInviteReplyPayload
+	if err := payload.Decode(&reply); err != nil {
+		sendSystemError(client, "Некорректные данные для команды call_accept.")
+		return
+	}
+
+	manager := GetManager()
+	invite := manager.takeInvite(reply.InviteID, client.Username)
+	if invite == nil {
+		sendSystemError(client, "Приглашение не найдено или истекло.")
+		return
+	}
+
+	logger.Infof("'%s' принял звонок от '%s'", invite.To, invite.From)
+	manager.sendToUser(invite.From, invite.status(CallInviteAccepted))
+
+	sfu.JoinCall(client, common.JoinCallPayload{RoomID: invite.RoomID, AudioMix: reply.AudioMix})
+}
+
+// HandleCallDecline rejects an incoming call
+func HandleCallDecline(client *Client, payload common.Payload) {
+	var reply common.CallInviteReplyPayload
+	if err := payload.Decode(&reply); err != nil {
+		sendSystemError(client, "Некорректные данные для команды call_decline.")
+		return
+	}
+
+	manager := GetManager()
+	invite := manager.takeInvite(reply.InviteID, client.Username)
+	if invite == nil {
+		sendSystemError(client, "Приглашение не найдено или истекло.")
+		return
+	}
+
+	logger.Infof("'%s' отклонил звонок от '%s'", invite.To, invite.From)
+	manager.sendToUser(invite.From, invite.status(CallInviteDeclined))
+}
+
+// takeInvite removes a pending invite addressed to the user.
+// An empty username matches any invite.
+func (manager *Manager) takeInvite(inviteID string, username string) *callInvite {
+	manager.invitesMu.Lock()
+	defer manager.invitesMu.Unlock()
+
+	invite, ok := manager.invites[inviteID]
+	if !ok || (username != "" && invite.To != username) {
+		return nil
+	}
+	delete(manager.invites, inviteID)
+	invite.timer.Stop()
+	return invite
+}
+
+// expireInvite stops the ringing on both sides once nobody answered
+func (manager *Manager) expireInvite(inviteID string) {
+	invite := manager.takeInvite(inviteID, "")
+	if invite == nil {
+		return
+	}
+
+	logger.Debugf("Звонок от '%s' для '%s' не отвечен", invite.From, invite.To)
+	message := invite.status(CallInviteTimeout)
+	manager.sendToUser(invite.From, message)
+	manager.sendToUser(invite.To, message)
+}
+
+// HandleCallEnded cancels the invites to a finished call and tells its
+// participants and everyone who was still being called
+func HandleCallEnded(event sfu.Event) {
+	manager := GetManager()
+
+	manager.invitesMu.Lock()
+	var cancelled []*callInvite
+	for id, invite := range manager.invites {
+		if invite.RoomID == event.RoomID {
+			invite.timer.Stop()
+			delete(manager.invites, id)
+			cancelled = append(cancelled, invite)
+		}
+	}
+	manager.invitesMu.Unlock()
+
+	recipients := make(map[string]bool)
+	for _, invite := range cancelled {
+		message := invite.status(CallInviteCancelled)
+		manager.sendToUser(invite.From, message)
+		manager.sendToUser(invite.To, message)
+		recipients[invite.From] = true
+		recipients[invite.To] = true
+	}
+	if payload, ok := event.Payload.(common.CallEndedPayload); ok {
+		for _, username := range payload.Participants {
+			recipients[username] = true
+		}
+	}
+
+	message := common.NewMessage(event.Type, event.Payload)
+	for username := range recipients {
+		manager.sendToUser(username, message)
+	}
+}
+
+// HandleGetCallHistory sends the last calls the client took part in
+func HandleGetCallHistory(client *Client, payload common.Payload) {
+	var requestPayload common.GetCallHistoryPayload
+	requestPayload.Limit = 20
+
+	if !payload.Empty() {
+		if err := payload.Decode(&requestPayload); err != nil {
+			logger.Warnf("Не удалось распарсить payload для get_call_history: %v. Используем лимит по умолчанию.", err)
+		}
+	}
+
+	if requestPayload.Limit <= 0 || requestPayload.Limit > 100 {
+		requestPayload.Limit = 20
+	}
+
+	calls, err := database.GetCallHistory(database.GetDB(), client.Username, requestPayload.Limit)
+	if err != nil {
+		logger.Errorf("Не удалось получить историю звонков из БД: %v", err)
+		sendSystemError(client, "Не удалось загрузить историю звонков.")
+		return
+	}
+
+	client.Send(common.NewMessage(common.MessageTypeCallHistory, calls))
+}
+
+// clientsByUsername returns every connection of the user
+func (manager *Manager) clientsByUsername(username string) []*Client {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	var clients []*Client
+	for client := range manager.clients {
+		if client.Username == username {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+func (manager *Manager) onlineUsernames() []string {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	seen := make(map[string]bool, len(manager.clients))
+	usernames := make([]string, 0, len(manager.clients))
+	for client := range manager.clients {
+		if !seen[client.Username] {
+			seen[client.Username] = true
+			usernames = append(usernames, client.Username)
+		}
+	}
+	return usernames
+}
+
+func (manager *Manager) sendToUser(username string, message *common.OutgoingMessage) {
+	for _, client := range manager.clientsByUsername(username) {
+		client.Send(message)
+	}
+}
@@ -1,6 +1,11 @@
 package ws
 
-import "log"
+import (
+	"fmt"
+	"server/common"
+
+	"github.com/gorilla/websocket"
+)
 
 func (c *Client) GetUsername() string {
 	return c.Username
@@ -10,10 +15,38 @@ func (c *Client) GetRole() string {
 	return c.Role
 }
 
-func (c *Client) Send(message []byte) {
-	select {
-	case c.send <- message:
-	default:
-		log.Printf("Channel for message sending is overflow. Client %s ignored.", c.Username)
+func (c *Client) Send(message *common.OutgoingMessage) {
+	c.sendClassified(message, ClassifyMessage(message.Type))
+}
+
+func (c *Client) sendClassified(message *common.OutgoingMessage, class MessageClass) {
+	data, err := message.Encode(c.encoding)
+	if err != nil {
+		logger.Errorf("Error encoding %s for %s: %v", message.Type, c.Username, err)
+		return
+	}
+
+	c.enqueue(data, class)
+}
+
+// frameType is the websocket frame type matching the client encoding
+func (c *Client) frameType() int {
+	if c.encoding == common.EncodingMsgpack {
+		return websocket.BinaryMessage
+	}
+	return websocket.TextMessage
+}
+
+// decodeFrame parses a client frame with the negotiated encoding, frames
+// of the other type are rejected
+func (c *Client) decodeFrame(frameType int, data []byte) (common.Message, error) {
+	if frameType != c.frameType() {
+		return common.Message{}, fmt.Errorf("unexpected frame type %d for %s encoding", frameType, c.encoding)
 	}
+	return common.DecodeMessage(c.encoding, data)
+}
+
+// closeSend closes the send queue so writePump can finish
+func (c *Client) closeSend() {
+	c.queue.close()
 }
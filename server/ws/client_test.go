@@ -0,0 +1,33 @@
+package ws
+
+import (
+	"server/common"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestDecodeFrameRejectsOtherEncoding(t *testing.T) {
+	hello := common.NewMessage(common.MessageTypeHello, common.HelloPayload{})
+
+	for _, encoding := range []common.Encoding{common.EncodingJSON, common.EncodingMsgpack} {
+		t.Run(string(encoding), func(t *testing.T) {
+			client := &Client{Username: "alice", encoding: encoding}
+			data, err := hello.Encode(encoding)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+
+			if _, err := client.decodeFrame(client.frameType(), data); err != nil {
+				t.Errorf("negotiated frame rejected: %v", err)
+			}
+			other := websocket.TextMessage
+			if client.frameType() == websocket.TextMessage {
+				other = websocket.BinaryMessage
+			}
+			if _, err := client.decodeFrame(other, data); err == nil {
+				t.Error("a frame of the other type was accepted")
+			}
+		})
+	}
+}
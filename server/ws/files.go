@@ -0,0 +1,110 @@
+package ws
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path"
+	"server/common"
+	"server/database"
+	"server/files"
+	"strings"
+)
+
+// HandleGetFileToken gives the client a token for /upload and /files/
+func HandleGetFileToken(client *Client) {
+	token, expiresAt := files.IssueToken(client.Username, client.Role)
+	client.Send(common.NewMessage(common.MessageTypeFileToken, common.FileTokenPayload{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}))
+}
+
+// HandleGetFileURL signs a short-lived download url for a file the client can access
+func HandleGetFileURL(client *Client, payload common.Payload) {
+	var request common.GetFileURLPayload
+	if err := payload.Decode(&request); err != nil {
+		sendSystemError(client, "Некорректные данные для команды get_file_url.")
+		return
+	}
+
+	name := strings.TrimPrefix(request.Name, files.URL(""))
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" || !files.CanAccess(client.Username, client.Role, name) {
+		sendSystemError(client, "Файл не найден.")
+		return
+	}
+
+	url, expiresAt := files.SignedURL(name)
+	client.Send(common.NewMessage(common.MessageTypeFileURL, common.FileURLPayload{
+		Name:      name,
+		URL:       url,
+		ExpiresAt: expiresAt,
+	}))
+}
+
+// maxAttachments is how many files one chat message may carry
+const maxAttachments = 10
+
+// checkAttachments loads the files of an attachment message, the sender must
+// be able to download every one of them. The chat is seen by every user, so
+// files limited to a conversation can't be attached.
+func checkAttachments(client *Client, fileIDs []string) ([]database.File, bool) {
+	if len(fileIDs) == 0 {
+		sendSystemError(client, "Прикрепите хотя бы один файл.")
+		return nil, false
+	}
+	if len(fileIDs) > maxAttachments {
+		sendSystemError(client, fmt.Sprintf("Можно прикрепить не больше %d файлов.", maxAttachments))
+		return nil, false
+	}
+
+	db := database.GetDB()
+	attached := make([]database.File, 0, len(fileIDs))
+	seen := make(map[string]bool, len(fileIDs))
+	for _, fileID := range fileIDs {
+		if seen[fileID] {
+			sendSystemError(client, "Файл прикреплён дважды.")
+			return nil, false
+		}
+		seen[fileID] = true
+
+		file, err := database.GetFile(db, fileID)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				logger.Errorf("Не удалось получить файл %s из БД: %v", fileID, err)
+			}
+			sendSystemError(client, "Файл не найден.")
+			return nil, false
+		}
+		if !files.CanAccess(client.Username, client.Role, file.Name) {
+			sendSystemError(client, "Файл не найден.")
+			return nil, false
+		}
+		if file.Conversation != "" {
+			sendSystemError(client, "Файл доступен только участникам звонка, его нельзя отправить в общий чат.")
+			return nil, false
+		}
+		attached = append(attached, file)
+	}
+	return attached, true
+}
+
+// chatAttachments is the metadata of stored files as sent in chat messages
+func chatAttachments(attached []database.File) []common.Attachment {
+	if len(attached) == 0 {
+		return nil
+	}
+	attachments := make([]common.Attachment, 0, len(attached))
+	for _, file := range attached {
+		attachments = append(attachments, common.Attachment{
+			ID:           file.Name,
+			OriginalName: file.OriginalName,
+			Size:         file.Size,
+			MimeType:     file.MimeType,
+			Checksum:     file.Checksum,
+			URL:          files.URL(file.Name),
+		})
+	}
+	return attachments
+}
@@ -1,16 +1,17 @@
 package ws
 
 import (
-	"encoding/json"
 	"net/http"
 	"server/common"
 	"server/database"
+	"server/sfu"
 
 	"github.com/gorilla/websocket"
 )
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool { return true },
+	CheckOrigin:  func(r *http.Request) bool { return true },
+	Subprotocols: common.Subprotocols,
 }
 
 func HandleWS(w http.ResponseWriter, r *http.Request) {
@@ -29,6 +30,12 @@ func HandleWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if sfu.ReservedUsername(username) {
+		logger.Warnf("Reserved username %s refused", username)
+		http.Error(w, "Username is reserved", http.StatusForbidden)
+		return
+	}
+
 	db := database.GetDB()
 
 	role, err := database.CreateUser(db, username, password)
@@ -45,12 +52,25 @@ func HandleWS(w http.ResponseWriter, r *http.Request) {
 
 	manager := GetManager()
 
+	encoding := common.EncodingForSubprotocol(conn.Subprotocol())
+	logger.Debugf("%s negotiated %s encoding", username, encoding)
+
 	client := &Client{
 		Username: username,
 		Role:     role,
 		manager:  manager,
 		conn:     conn,
-		send:     make(chan []byte, 256),
+		queue:    newSendQueue(),
+		kick:     make(chan closeFrame, 1),
+		resync:   make(chan struct{}, 1),
+		encoding: encoding,
+	}
+
+	if err := client.handshake(); err != nil {
+		if err := conn.Close(); err != nil {
+			logger.Debugf("Close connection after failed handshake: %v", err)
+		}
+		return
 	}
 
 	client.manager.register <- client
@@ -60,14 +80,14 @@ func HandleWS(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func HandleGetMessages(client *Client, payload json.RawMessage) {
+func HandleGetMessages(client *Client, payload common.Payload) {
 	logger.Tracef("Клиент %s запросил историю сообщений", client.Username)
 
 	var requestPayload common.GetMessagesPayload
 	requestPayload.Limit = 50
 
-	if payload != nil {
-		if err := json.Unmarshal(payload, &requestPayload); err != nil {
+	if !payload.Empty() {
+		if err := payload.Decode(&requestPayload); err != nil {
 			logger.Warnf("Не удалось распарсить payload для get_messages_request: %v. Используем лимит по умолчанию.", err)
 		}
 	}
@@ -85,31 +105,25 @@ func HandleGetMessages(client *Client, payload json.RawMessage) {
 		return
 	}
 
-	payloadBytes, err := json.Marshal(messages)
-	if err != nil {
-		logger.Errorf("Не удалось упаковать payload с сообщениями: %v", err)
-		return
-	}
-
-	responseMsg := common.Message{
-		Type:    common.MessageTypeGetMessagesResponse,
-		Payload: payloadBytes,
-	}
-
-	responseBytes, err := json.Marshal(responseMsg)
-	if err != nil {
-		logger.Errorf("Не удалось упаковать финальное сообщение с историей: %v", err)
-		return
+	history := make([]common.ServerChatPayload, 0, len(messages))
+	for _, msg := range messages {
+		history = append(history, common.ServerChatPayload{
+			Sender:      msg.Sender,
+			Role:        msg.Role,
+			Type:        msg.Type,
+			Content:     msg.Content,
+			Attachments: chatAttachments(msg.Attachments),
+		})
 	}
 
-	client.send <- responseBytes
+	client.Send(common.NewMessage(common.MessageTypeGetMessagesResponse, history))
 
 	logger.Tracef("Отправлено %d сообщений из истории клиенту %s", len(messages), client.Username)
 }
 
-func HandleChat(client *Client, payload json.RawMessage) {
+func HandleChat(client *Client, payload common.Payload) {
 	var clientPayload common.ClientChatPayload
-	err := json.Unmarshal(payload, &clientPayload)
+	err := payload.Decode(&clientPayload)
 	if err != nil {
 		logger.Errorf("Error unmarshalling payload: %v", err)
 		return
@@ -122,31 +136,25 @@ func HandleChat(client *Client, payload json.RawMessage) {
 		Content: clientPayload.Content,
 	}
 
-	serverPayloadBytes, err := json.Marshal(serverPayload)
-	if err != nil {
-		logger.Errorf("Error marshalling serverPayload: %v", err)
+	if clientPayload.Type == common.ChatTypeAttachment {
+		attached, ok := checkAttachments(client, clientPayload.FileIDs)
+		if !ok {
+			return
+		}
+		serverPayload.Attachments = chatAttachments(attached)
+	} else if len(clientPayload.FileIDs) > 0 {
+		sendSystemError(client, "Файлы можно прикрепить только к сообщению типа attachment.")
 		return
 	}
 
 	go func() {
 		db := database.GetDB()
-		if err := database.InsertMessage(db, serverPayload.Sender, serverPayload.Role, serverPayload.Type, serverPayload.Content); err != nil {
+		if err := database.InsertMessage(db, serverPayload.Sender, serverPayload.Role, serverPayload.Type, serverPayload.Content, clientPayload.FileIDs...); err != nil {
 			logger.Errorf("Не удалось сохранить сообщение в БД: %v", err)
 		}
 	}()
 
-	broadcastMessage := common.Message{
-		Type:    common.MessageTypeChat,
-		Payload: serverPayloadBytes,
-	}
-
-	broadcastMessageBytes, err := json.Marshal(broadcastMessage)
-	if err != nil {
-		logger.Errorf("Error marshalling broadcast message: %v", err)
-		return
-	}
-
-	client.manager.broadcast <- broadcastMessageBytes
+	client.manager.broadcast <- common.NewMessage(common.MessageTypeChat, serverPayload)
 
 }
 
@@ -165,33 +173,17 @@ func GetWSClients(context common.ClientContext) {
 		})
 	}
 
-	activeClientsInfoBytes, err := json.Marshal(activeClientsInfo)
-	if err != nil {
-		logger.Errorf("Error marshalling activeClientsInfo: %v", err)
-		return
-	}
-
-	clientsToSend := common.Message{
-		Type:    common.MessageTypeActiveClientsWSResponse,
-		Payload: activeClientsInfoBytes,
-	}
-
-	clientsToSendBytes, err := json.Marshal(clientsToSend)
-	if err != nil {
-		logger.Errorf("Error marshalling activeClientsInfo: %v", err)
-	}
-
-	context.Send(clientsToSendBytes)
+	context.Send(common.NewMessage(common.MessageTypeActiveClientsWSResponse, activeClientsInfo))
 }
 
-func HandlePromoteUser(client *Client, payload json.RawMessage) {
+func HandlePromoteUser(client *Client, payload common.Payload) {
 	if client.Role != "admin" {
 		sendSystemError(client, "У вас нет прав для выполнения этой команды.")
 		return
 	}
 
 	var promotePayload common.PromoteUserPayload
-	if err := json.Unmarshal(payload, &promotePayload); err != nil {
+	if err := payload.Decode(&promotePayload); err != nil {
 		sendSystemError(client, "Некорректные данные для команды promote_user.")
 		return
 	}
@@ -230,56 +222,19 @@ func HandlePromoteUser(client *Client, payload json.RawMessage) {
 
 func sendSystemError(client *Client, errorMessage string) {
 	errorPayload := map[string]string{"error": errorMessage}
-	payloadBytes, err := json.Marshal(errorPayload)
-	if err != nil {
-		logger.Errorf("Error marshalling errorMessage: %v", err)
-		return
-	}
-
-	errorMessageToSend := common.Message{
-		Type:    common.MessageTypeSystemError,
-		Payload: payloadBytes,
-	}
-
-	errorMessageToSendBytes, err := json.Marshal(errorMessageToSend)
-	if err != nil {
-		logger.Errorf("Error marshalling errorMessage: %v", err)
-		return
-	}
-
-	client.send <- errorMessageToSendBytes
+	client.Send(common.NewMessage(common.MessageTypeSystemError, errorPayload))
 }
 
 func sendUpdatedUserToAll(updatedClientUsername string, updatedClientRole string) {
 	logger.Debugf("Try send promoting %s :: %s", updatedClientUsername, updatedClientRole)
 	manager := GetManager()
 
-	manager.mu.RLock()
-	defer manager.mu.RUnlock()
-
 	updatedClientPayload := common.PromoteUserPayload{
 		Username: updatedClientUsername,
 		NewRole:  updatedClientRole,
 	}
 
-	updatedClientPayloadBytes, err := json.Marshal(updatedClientPayload)
-	if err != nil {
-		logger.Errorf("Error marshalling activeClientsInfo: %v", err)
-		return
-	}
-
-	clientToSend := common.Message{
-		Type:    common.MessageTypePromoteUserResponse,
-		Payload: updatedClientPayloadBytes,
-	}
-
-	clientToSendBytes, err := json.Marshal(clientToSend)
-	if err != nil {
-		logger.Errorf("Error marshalling clientToSend: %v", err)
-		return
-	}
-
-	manager.broadcast <- clientToSendBytes
+	manager.broadcast <- common.NewMessage(common.MessageTypePromoteUserResponse, updatedClientPayload)
 }
 
 func HandleJoinUserResponse(username string, role string) {
@@ -290,22 +245,7 @@ func HandleJoinUserResponse(username string, role string) {
 		"role":     role,
 	}
 
-	joinPayloadBytes, err := json.Marshal(joinPayload)
-	if err != nil {
-		logger.Errorf("Error marshalling joinPayload: %v", err)
-	}
-
-	joinUserNotificationMessage := common.Message{
-		Type:    common.MessageTypeUserJoinWS,
-		Payload: joinPayloadBytes,
-	}
-
-	joinUserNotificationMessageBytes, err := json.Marshal(joinUserNotificationMessage)
-	if err != nil {
-		logger.Errorf("Error marshalling joinUserNotificationMessage: %v", err)
-	}
-
-	wsManager.broadcast <- joinUserNotificationMessageBytes
+	wsManager.broadcast <- common.NewMessage(common.MessageTypeUserJoinWS, joinPayload)
 }
 
 func HandleLeaveUserResponse(username string, role string) {
@@ -316,45 +256,16 @@ func HandleLeaveUserResponse(username string, role string) {
 		"role":     role,
 	}
 
-	joinPayloadBytes, err := json.Marshal(joinPayload)
-	if err != nil {
-		logger.Errorf("Error marshalling joinPayload: %v", err)
-	}
-
-	joinUserNotificationMessage := common.Message{
-		Type:    common.MessageTypeUserLeaveWS,
-		Payload: joinPayloadBytes,
-	}
-
-	joinUserNotificationMessageBytes, err := json.Marshal(joinUserNotificationMessage)
-	if err != nil {
-		logger.Errorf("Error marshalling joinUserNotificationMessage: %v", err)
-	}
-
-	wsManager.broadcast <- joinUserNotificationMessageBytes
+	wsManager.broadcast <- common.NewMessage(common.MessageTypeUserLeaveWS, joinPayload)
 }
 
-func HandleSFUEventResponse(username string, eventType string) {
+func HandleSFUEventResponse(username string, roomID string, eventType string) {
 	wsManager := GetManager()
 
 	joinPayload := map[string]string{
 		"username": username,
+		"room_id":  roomID,
 	}
 
-	joinPayloadBytes, err := json.Marshal(joinPayload)
-	if err != nil {
-		logger.Errorf("Error marshalling joinPayload: %v", err)
-	}
-
-	joinUserNotificationMessage := common.Message{
-		Type:    eventType,
-		Payload: joinPayloadBytes,
-	}
-
-	joinUserNotificationMessageBytes, err := json.Marshal(joinUserNotificationMessage)
-	if err != nil {
-		logger.Errorf("Error marshalling joinUserNotificationMessage: %v", err)
-	}
-
-	wsManager.broadcast <- joinUserNotificationMessageBytes
+	wsManager.broadcast <- common.NewMessage(eventType, joinPayload)
 }
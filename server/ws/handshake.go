@@ -0,0 +1,108 @@
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"server/common"
+	"sort"
+	"time"
+)
+
+const (
+	helloWait = 10 * time.Second
+
+	// CloseUnsupportedProtocol is sent when hello is missing or the version is too old
+	CloseUnsupportedProtocol = 4001
+)
+
+var errHandshake = errors.New("handshake failed")
+
+// handshake waits for the hello frame and answers with hello_ack.
+// It runs before the pumps start, so it owns the connection.
+func (c *Client) handshake() error {
+	if err := c.conn.SetReadDeadline(time.Now().Add(helloWait)); err != nil {
+		return err
+	}
+
+	frameType, data, err := c.conn.ReadMessage()
+	if err != nil {
+		logger.Warnf("No hello from %s: %v", c.Username, err)
+		return err
+	}
+
+	if err := c.conn.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	message, err := c.decodeFrame(frameType, data)
+	if err != nil || message.Type != common.MessageTypeHello {
+		c.rejectHandshake("Первым сообщением должен быть hello.")
+		return errHandshake
+	}
+
+	var hello common.HelloPayload
+	if err := message.Payload.Decode(&hello); err != nil {
+		c.rejectHandshake("Некорректный payload для hello.")
+		return errHandshake
+	}
+
+	if hello.ProtocolVersion < common.MinProtocolVersion {
+		c.rejectHandshake(fmt.Sprintf(
+			"Версия протокола %d не поддерживается, минимальная версия %d.",
+			hello.ProtocolVersion, common.MinProtocolVersion,
+		))
+		return errHandshake
+	}
+
+	c.protocolVersion = hello.ProtocolVersion
+	if c.protocolVersion > common.ProtocolVersion {
+		c.protocolVersion = common.ProtocolVersion
+	}
+	c.capabilities = common.NegotiateCapabilities(hello.Capabilities)
+
+	if c.encoding == common.EncodingMsgpack && !c.Supports(common.CapabilityBinary) {
+		c.rejectHandshake("Подпротокол patterns.msgpack требует capability binary.")
+		return errHandshake
+	}
+
+	negotiated := make([]string, 0, len(c.capabilities))
+	for capability := range c.capabilities {
+		negotiated = append(negotiated, capability)
+	}
+	sort.Strings(negotiated)
+
+	logger.Infof("%s speaks protocol v%d with capabilities %v", c.Username, c.protocolVersion, negotiated)
+
+	return c.writeDirect(common.NewMessage(common.MessageTypeHelloAck, common.HelloAckPayload{
+		ProtocolVersion: c.protocolVersion,
+		Capabilities:    negotiated,
+		Username:        c.Username,
+		Role:            c.Role,
+	}))
+}
+
+// rejectHandshake explains the problem to the client and closes the socket
+func (c *Client) rejectHandshake(reason string) {
+	logger.Warnf("Rejecting %s: %s", c.Username, reason)
+
+	errorPayload := map[string]string{"error": reason}
+	if err := c.writeDirect(common.NewMessage(common.MessageTypeSystemError, errorPayload)); err != nil {
+		logger.Errorf("Write handshake error failed: %v", err)
+	}
+	c.disconnect(CloseUnsupportedProtocol, "unsupported protocol")
+}
+
+// writeDirect writes to the socket bypassing the send queue.
+// Only safe before writePump is started.
+func (c *Client) writeDirect(message *common.OutgoingMessage) error {
+	data, err := message.Encode(c.encoding)
+	if err != nil {
+		return err
+	}
+	return c.conn.WriteMessage(c.frameType(), data)
+}
+
+// Supports reports whether the capability was negotiated in hello
+func (c *Client) Supports(capability string) bool {
+	return c.capabilities[capability]
+}
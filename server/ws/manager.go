@@ -1,7 +1,6 @@
 package ws
 
 import (
-	"encoding/json"
 	"server/common"
 	"server/sfu"
 	"sync"
@@ -20,7 +19,10 @@ func GetManager() *Manager {
 			clients:    make(map[*Client]bool),
 			register:   make(chan *Client),
 			unregister: make(chan *Client),
-			broadcast:  make(chan []byte),
+			broadcast:  make(chan *common.OutgoingMessage),
+			policies:   DefaultBackpressurePolicies(),
+			drops:      make(map[MessageClass]uint64),
+			invites:    make(map[string]*callInvite),
 		}
 	})
 	return managerInstance
@@ -30,31 +32,39 @@ func (manager *Manager) Run() {
 	for {
 		select {
 		case client := <-manager.register:
+			manager.mu.Lock()
 			manager.clients[client] = true
+			manager.mu.Unlock()
 			go HandleJoinUserResponse(client.Username, client.Role)
+			if client.Supports(common.CapabilityResume) {
+				go sfu.ResumeCall(client)
+			}
 
 		case client := <-manager.unregister:
-			if _, ok := manager.clients[client]; ok {
-				delete(manager.clients, client)
-				close(client.send)
+			manager.mu.Lock()
+			_, ok := manager.clients[client]
+			delete(manager.clients, client)
+			manager.mu.Unlock()
+			if ok {
+				client.closeSend()
 				go HandleLeaveUserResponse(client.Username, client.Role)
 			}
 
 		case message := <-manager.broadcast:
+			class := ClassifyMessage(message.Type)
+			manager.mu.RLock()
 			for client := range manager.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(manager.clients, client)
-				}
+				client.sendClassified(message, class)
 			}
+			manager.mu.RUnlock()
 		case event := <-sfu.EventsChannel:
 			switch event.Type {
 			case common.MessageTypeUserJoinSFU:
-				go HandleSFUEventResponse(event.InitiatorUsername, event.Type)
+				go HandleSFUEventResponse(event.InitiatorUsername, event.RoomID, event.Type)
 			case common.MessageTypeUserLeaveSFU:
-				go HandleSFUEventResponse(event.InitiatorUsername, event.Type)
+				go HandleSFUEventResponse(event.InitiatorUsername, event.RoomID, event.Type)
+			case common.MessageTypeCallEnded:
+				go HandleCallEnded(event)
 			default:
 				logger.Warnf("Unknown sfu event type %d", event.Type)
 			}
@@ -66,7 +76,8 @@ func (manager *Manager) Run() {
 func (c *Client) readPump() {
 	defer func() {
 		c.manager.unregister <- c
-		sfu.GetManager().RemoveClient(c.Username)
+		// The call survives a short reconnect
+		sfu.GetManager().DetachClient(c)
 		err := c.conn.Close()
 		if err != nil {
 			logger.Errorf("Close connection failed: %v", err)
@@ -74,7 +85,7 @@ func (c *Client) readPump() {
 	}()
 
 	for {
-		_, messagePayload, err := c.conn.ReadMessage()
+		frameType, messagePayload, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				logger.Errorf("Unexpected ws close for %s", c.Username)
@@ -84,10 +95,10 @@ func (c *Client) readPump() {
 			break
 		}
 
-		var message common.Message
-		err = json.Unmarshal(messagePayload, &message)
+		message, err := c.decodeFrame(frameType, messagePayload)
 		if err != nil {
-			logger.Errorf("Error unmarshalling message: %s\n", err)
+			logger.Errorf("Error unmarshalling message from %s: %s\n", c.Username, err)
+			sendSystemError(c, "Не удалось разобрать сообщение.")
 			continue
 		}
 
@@ -99,7 +110,7 @@ func (c *Client) readPump() {
 		case common.MessageTypeGetMessagesRequest:
 			HandleGetMessages(c, message.Payload)
 		case common.MessageTypeJoinCall:
-			sfu.HandleJoinCall(c)
+			sfu.HandleJoinCall(c, message.Payload)
 		case common.MessageTypeIceCandidate:
 			sfu.HandleICECandidate(c.Username, message.Payload)
 		case common.MessageTypeSdpAnswer:
@@ -112,6 +123,36 @@ func (c *Client) readPump() {
 			sfu.GetSFUClients(c)
 		case common.MessageTypePromoteUser:
 			HandlePromoteUser(c, message.Payload)
+		case common.MessageTypeSetTrackSource:
+			sfu.HandleSetTrackSource(c, message.Payload)
+		case common.MessageTypeSetPreferredLayer:
+			sfu.HandleSetPreferredLayer(c, message.Payload)
+		case common.MessageTypeCallMuteParticipant:
+			sfu.HandleMuteParticipant(c, message.Payload)
+		case common.MessageTypeCallRequestUnmute:
+			sfu.HandleRequestUnmute(c, message.Payload)
+		case common.MessageTypeCallRemoveParticipant:
+			sfu.HandleRemoveParticipant(c, message.Payload)
+		case common.MessageTypeCallStartRecording:
+			sfu.HandleStartRecording(c)
+		case common.MessageTypeCallStopRecording:
+			sfu.HandleStopRecording(c)
+		case common.MessageTypeCallPlayMedia:
+			sfu.HandleCallPlayMedia(c, message.Payload)
+		case common.MessageTypeGetCallStats:
+			sfu.HandleGetCallStats(c, message.Payload)
+		case common.MessageTypeCallInvite:
+			HandleCallInvite(c, message.Payload)
+		case common.MessageTypeCallAccept:
+			HandleCallAccept(c, message.Payload)
+		case common.MessageTypeCallDecline:
+			HandleCallDecline(c, message.Payload)
+		case common.MessageTypeGetCallHistory:
+			HandleGetCallHistory(c, message.Payload)
+		case common.MessageTypeGetFileToken:
+			HandleGetFileToken(c)
+		case common.MessageTypeGetFileURL:
+			HandleGetFileURL(c, message.Payload)
 		case common.MessageTypeLeaveCall:
 			sfu.GetManager().RemoveClient(c.Username)
 		default:
@@ -130,21 +171,41 @@ func (c *Client) writePump() {
 	}()
 
 	for {
-		message, ok := <-c.send
-		if !ok {
-			err := c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-			if err != nil {
+		select {
+		case frame := <-c.kick:
+			c.disconnect(frame.code, frame.reason)
+			return
 
-				logger.Errorf("Write close message error: %v", err)
+		case <-c.resync:
+			// Sent as soon as something is dropped, not behind the full queue
+			if missed := c.takeMissed(); missed > 0 {
+				logger.Infof("Client %s missed %d messages, asking to resync", c.Username, missed)
+				if err := c.conn.WriteMessage(c.frameType(), c.resyncMessage(missed)); err != nil {
+					logger.Errorf("Write resync message error: %v", err)
+					return
+				}
 			}
-			logger.Error("Special write pump error")
-			return
-		}
 
-		err := c.conn.WriteMessage(websocket.TextMessage, message)
-		if err != nil {
-			logger.Errorf("Write message error: %v", err)
-			return
+		case <-c.queue.ready:
+			message, ok, done := c.queue.pop()
+			if done {
+				err := c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				if err != nil {
+
+					logger.Errorf("Write close message error: %v", err)
+				}
+				logger.Error("Special write pump error")
+				return
+			}
+			if !ok {
+				continue
+			}
+
+			err := c.conn.WriteMessage(c.frameType(), message.data)
+			if err != nil {
+				logger.Errorf("Write message error: %v", err)
+				return
+			}
 		}
 	}
 }
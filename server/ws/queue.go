@@ -0,0 +1,82 @@
+package ws
+
+import "sync"
+
+// queuedMessage is an encoded message waiting for writePump
+type queuedMessage struct {
+	data  []byte
+	class MessageClass
+}
+
+// sendQueue is the bounded outgoing queue of a client. Entries keep their
+// class so backpressure evicts only messages that may be dropped.
+type sendQueue struct {
+	mu     sync.Mutex
+	items  []queuedMessage
+	closed bool
+	// ready wakes writePump after a push or close
+	ready chan struct{}
+}
+
+func newSendQueue() *sendQueue {
+	return &sendQueue{ready: make(chan struct{}, 1)}
+}
+
+func (q *sendQueue) wake() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// push appends a message, false if the queue is full. Must be called with q.mu held.
+func (q *sendQueue) push(message queuedMessage) bool {
+	if len(q.items) >= sendBufferSize {
+		return false
+	}
+	q.items = append(q.items, message)
+	q.wake()
+	return true
+}
+
+// evictOldest removes the oldest message of a droppable class.
+// Must be called with q.mu held.
+func (q *sendQueue) evictOldest(droppable func(MessageClass) bool) (MessageClass, bool) {
+	for i, message := range q.items {
+		if droppable(message.class) {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			return message.class, true
+		}
+	}
+	return "", false
+}
+
+// pop takes the oldest message. done is set once the queue is closed and
+// empty. writePump is woken again while messages are left.
+func (q *sendQueue) pop() (message queuedMessage, ok bool, done bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return queuedMessage{}, false, q.closed
+	}
+	message = q.items[0]
+	q.items[0] = queuedMessage{}
+	q.items = q.items[1:]
+	if len(q.items) > 0 || q.closed {
+		q.wake()
+	}
+	return message, true, false
+}
+
+// close lets writePump finish once the queue is drained
+func (q *sendQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.wake()
+}
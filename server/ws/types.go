@@ -1,7 +1,9 @@
 package ws
 
 import (
+	"server/common"
 	"sync"
+	"sync/atomic"
 
 	"github.com/gorilla/websocket"
 )
@@ -10,14 +12,37 @@ type Manager struct {
 	clients    map[*Client]bool
 	register   chan *Client
 	unregister chan *Client
-	broadcast  chan []byte
-	mu         sync.RWMutex
+	broadcast  chan *common.OutgoingMessage
+	policies   map[MessageClass]BackpressurePolicy
+	policiesMu sync.RWMutex
+	drops      map[MessageClass]uint64
+	dropsMu    sync.Mutex
+	// invites are the incoming calls still ringing, by invite id
+	invites   map[string]*callInvite
+	invitesMu sync.Mutex
+	mu        sync.RWMutex
+}
+
+// closeFrame is the code and reason of a close requested by the server
+type closeFrame struct {
+	code   int
+	reason string
 }
 
 type Client struct {
-	Username string
-	Role     string
-	manager  *Manager
-	conn     *websocket.Conn
-	send     chan []byte
+	Username  string
+	Role      string
+	manager   *Manager
+	conn      *websocket.Conn
+	queue     *sendQueue
+	closeOnce sync.Once
+	missed    atomic.Uint64
+	encoding  common.Encoding
+	// kick asks writePump to close the socket, the hub must never block on a client
+	kick chan closeFrame
+	// resync asks writePump to send resync_required ahead of the queue
+	resync chan struct{}
+
+	protocolVersion int
+	capabilities    map[string]bool
 }